@@ -1,6 +1,9 @@
 package main
 
 import (
+	// embeds the time zone database, restaurant schedules are evaluated in their own time zone
+	_ "time/tzdata"
+
 	"github.com/sebastianreh/distance-calculator-api/cmd/httpserver"
 	"github.com/sebastianreh/distance-calculator-api/internal/container"
 )
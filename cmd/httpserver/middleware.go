@@ -55,6 +55,8 @@ func HTTPErrorHandler(err error, ctx echo.Context) {
 		apiError = resterror.NewNotFoundError(err.Error())
 	case exceptions.UnauthorizedException:
 		apiError = resterror.NewUnauthorizedError(err.Error())
+	case exceptions.BadRequestException:
+		apiError = resterror.NewBadRequestError(err.Error())
 	default:
 		apiError = resterror.NewInternalServerError(err.Error(), err)
 	}
@@ -9,4 +9,12 @@ func (s *Server) Routes() {
 
 	calculatorGroup.POST("/preprocess", s.dependencies.CalculatorHandler.PreprocessRestaurants)
 	calculatorGroup.GET("/restaurants", s.dependencies.CalculatorHandler.Calculate)
+	calculatorGroup.POST("/restaurants/batch", s.dependencies.CalculatorHandler.CalculateBatch)
+	calculatorGroup.GET("/restaurants/:id/deliverable", s.dependencies.CalculatorHandler.CheckDeliverability)
+	calculatorGroup.POST("/overrides", s.dependencies.CalculatorHandler.CreateOverride)
+	calculatorGroup.GET("/overrides", s.dependencies.CalculatorHandler.ListOverrides)
+	calculatorGroup.DELETE("/overrides/:id", s.dependencies.CalculatorHandler.DeleteOverride)
+	calculatorGroup.POST("/exclusions", s.dependencies.CalculatorHandler.CreateExclusion)
+	calculatorGroup.GET("/exclusions", s.dependencies.CalculatorHandler.ListExclusions)
+	calculatorGroup.DELETE("/exclusions/:id", s.dependencies.CalculatorHandler.DeleteExclusion)
 }
@@ -0,0 +1,138 @@
+package calculator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	str "github.com/sebastianreh/distance-calculator-api/pkg/strings"
+)
+
+// datasetCache keeps the current dataset and its decoded time radius map, so requests only download the map
+// again when a new version is published. Maps are loaded without holding the lock, requests keep being served
+// the previous version meanwhile.
+type datasetCache struct {
+	mutex         sync.RWMutex
+	dataset       entities.Dataset
+	timeRadiusMap entities.TimeRadiusMap
+	refreshedAt   time.Time
+	// loads in flight by version, so a version is only loaded once however many requests need it
+	loading map[string]*datasetLoad
+}
+
+// datasetLoad is the load of a version's time radius map, done is closed once it finished
+type datasetLoad struct {
+	done          chan struct{}
+	timeRadiusMap entities.TimeRadiusMap
+	err           error
+}
+
+func (c *datasetCache) get() (entities.Dataset, entities.TimeRadiusMap, time.Time) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.dataset, c.timeRadiusMap, c.refreshedAt
+}
+
+// invalidate makes the next request read the dataset pointer again
+func (c *datasetCache) invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.refreshedAt = time.Time{}
+}
+
+// currentDataset returns the published dataset with its time radius map. The dataset pointer is read again once
+// DATASET_REFRESH_INTERVAL has passed, and the map is only loaded when the pointer moved to a new version.
+func (r *calculatorService) currentDataset(ctx context.Context) (entities.Dataset, entities.TimeRadiusMap, error) {
+	dataset, timeRadiusMap, refreshedAt := r.cache.get()
+	if !refreshedAt.IsZero() && time.Since(refreshedAt) < r.config.DatasetRefreshInterval {
+		return dataset, timeRadiusMap, nil
+	}
+
+	current, err := r.repository.GetCurrentDataset(ctx)
+	if err != nil {
+		return current, nil, err
+	}
+
+	return r.refreshDataset(ctx, current)
+}
+
+// refreshDataset caches the given dataset, loading its time radius map unless the version is already cached.
+// While another request loads the version, the previously cached dataset is returned, or the load awaited when
+// nothing is cached yet.
+func (r *calculatorService) refreshDataset(ctx context.Context,
+	current entities.Dataset) (entities.Dataset, entities.TimeRadiusMap, error) {
+	r.cache.mutex.Lock()
+	if !r.cache.refreshedAt.IsZero() && r.cache.dataset.Version == current.Version {
+		r.cache.refreshedAt = time.Now()
+		dataset, timeRadiusMap := r.cache.dataset, r.cache.timeRadiusMap
+		r.cache.mutex.Unlock()
+		return dataset, timeRadiusMap, nil
+	}
+	if current.IsEmpty() {
+		r.cache.dataset, r.cache.timeRadiusMap, r.cache.refreshedAt = current, nil, time.Now()
+		r.cache.mutex.Unlock()
+		return current, nil, nil
+	}
+
+	load, inFlight := r.cache.loading[current.Version]
+	if inFlight && !r.cache.refreshedAt.IsZero() {
+		dataset, timeRadiusMap := r.cache.dataset, r.cache.timeRadiusMap
+		r.cache.mutex.Unlock()
+		return dataset, timeRadiusMap, nil
+	}
+	if !inFlight {
+		load = &datasetLoad{done: make(chan struct{})}
+		if r.cache.loading == nil {
+			r.cache.loading = make(map[string]*datasetLoad)
+		}
+		r.cache.loading[current.Version] = load
+	}
+	r.cache.mutex.Unlock()
+
+	if inFlight {
+		select {
+		case <-load.done:
+			return current, load.timeRadiusMap, load.err
+		case <-ctx.Done():
+			return current, nil, ctx.Err()
+		}
+	}
+
+	load.timeRadiusMap, load.err = r.repository.GetTimeRadiusMapData(ctx, current.Version)
+	if load.err == nil {
+		r.logs.Info(fmt.Sprintf("Loaded dataset %s with %d restaurants", current.Version, len(load.timeRadiusMap)),
+			fmt.Sprintf("%s.%s", serviceName, "refreshDataset"))
+	}
+
+	r.cache.mutex.Lock()
+	delete(r.cache.loading, current.Version)
+	// a newer dataset may have been cached while this one was loading
+	if load.err == nil && !r.cache.dataset.CreatedAt.After(current.CreatedAt) {
+		r.cache.dataset, r.cache.timeRadiusMap, r.cache.refreshedAt = current, load.timeRadiusMap, time.Now()
+	}
+	r.cache.mutex.Unlock()
+	close(load.done)
+
+	if load.err != nil {
+		return current, nil, load.err
+	}
+	return current, load.timeRadiusMap, nil
+}
+
+// WatchDatasets loads every dataset published by any replica as soon as it is announced, until ctx is done.
+// Without notifications, the cache still catches up when the dataset pointer is refreshed.
+func (r *calculatorService) WatchDatasets(ctx context.Context) {
+	datasets, err := r.repository.WatchDatasets(ctx)
+	if err != nil {
+		r.logs.Warn(str.ErrorConcat(err, serviceName, "WatchDatasets"))
+		return
+	}
+
+	for dataset := range datasets {
+		if _, _, err = r.refreshDataset(ctx, dataset); err != nil {
+			r.logs.Error(str.ErrorConcat(err, serviceName, "WatchDatasets"))
+		}
+	}
+}
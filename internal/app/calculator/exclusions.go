@@ -0,0 +1,72 @@
+package calculator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/sebastianreh/distance-calculator-api/internal/entities/exceptions"
+)
+
+// currentExclusions returns the exclusion zones, loaded again once EXCLUSIONS_REFRESH_INTERVAL has passed, or
+// right after this replica changed them
+func (r *calculatorService) currentExclusions(ctx context.Context) (entities.Exclusions, error) {
+	return r.exclusions.get(ctx, r.config.ExclusionsRefreshInterval, r.repository.GetExclusions)
+}
+
+// excluded tells if deliveries into the point are blocked at now by an exclusion zone
+func (r *calculatorService) excluded(ctx context.Context, lat, long float64, now time.Time) (bool, error) {
+	exclusions, err := r.currentExclusions(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	_, excluded := exclusions.Excluding(lat, long, now)
+	return excluded, nil
+}
+
+// CreateExclusion stores a delivery exclusion zone, applied by every replica once they load the exclusions again
+func (r *calculatorService) CreateExclusion(ctx context.Context, exclusion entities.Exclusion) (entities.Exclusion,
+	error) {
+	exclusion, err := entities.NewExclusion(exclusion, time.Now())
+	if err != nil {
+		return exclusion, exceptions.NewBadRequestException(err.Error())
+	}
+
+	err = r.repository.SetExclusion(ctx, exclusion)
+	if err != nil {
+		return exclusion, err
+	}
+	r.exclusions.invalidate()
+
+	return exclusion, nil
+}
+
+// ListExclusions returns every exclusion zone, the oldest first
+func (r *calculatorService) ListExclusions(ctx context.Context) (entities.Exclusions, error) {
+	exclusions, err := r.repository.GetExclusions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(exclusions, func(i, j int) bool {
+		return exclusions[i].CreatedAt.Before(exclusions[j].CreatedAt)
+	})
+
+	return exclusions, nil
+}
+
+func (r *calculatorService) DeleteExclusion(ctx context.Context, id string) error {
+	deleted, err := r.repository.DeleteExclusion(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return exceptions.NewNotFoundException(fmt.Sprintf("exclusion %s not found", id))
+	}
+	r.exclusions.invalidate()
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package calculator
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"net/http"
 	"time"
@@ -8,15 +9,33 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/sebastianreh/distance-calculator-api/internal/config"
 	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/sebastianreh/distance-calculator-api/internal/entities/exceptions"
 	"github.com/sebastianreh/distance-calculator-api/pkg/logger"
 	str "github.com/sebastianreh/distance-calculator-api/pkg/strings"
 )
 
-const handlerName = "calculation.handler"
+const (
+	handlerName = "calculation.handler"
+	atParam     = "at"
+	limitParam  = "limit"
+	// header internal callers authenticate debug options, overrides and exclusions with
+	internalTokenHeader = "X-Internal-Token"
+	restaurantIDParam   = "restaurant_id"
+	// tolerated clock skew for an at value slightly in the past
+	pastTolerance = time.Minute
+)
 
 type CalculatorHandler interface {
 	Calculate(ctx echo.Context) error
+	CalculateBatch(ctx echo.Context) error
+	CheckDeliverability(ctx echo.Context) error
 	PreprocessRestaurants(ctx echo.Context) error
+	CreateOverride(ctx echo.Context) error
+	ListOverrides(ctx echo.Context) error
+	DeleteOverride(ctx echo.Context) error
+	CreateExclusion(ctx echo.Context) error
+	ListExclusions(ctx echo.Context) error
+	DeleteExclusion(ctx echo.Context) error
 }
 
 type calculatorHandler struct {
@@ -40,7 +59,62 @@ func (h *calculatorHandler) Calculate(ctx echo.Context) error {
 		ctx.Error(err)
 		return nil
 	}
-	request.Now = time.Now()
+	if request.Expand != "" && !request.ExpandsDetails() {
+		ctx.Error(echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid expand, supported values: %s",
+			entities.ExpandDetails)))
+		return nil
+	}
+
+	if request.Sort != "" && !entities.IsValidSort(request.Sort) {
+		ctx.Error(echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid sort, supported values: %s, %s, %s",
+			entities.SortDistance, entities.SortRating, entities.SortClosingSoon)))
+		return nil
+	}
+
+	// a missing limit returns every result, an explicit one must select at least one restaurant
+	if (ctx.QueryParam(limitParam) != "" && request.Limit < 1) || request.Limit > h.config.MaxPageSize {
+		ctx.Error(echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d",
+			h.config.MaxPageSize)))
+		return nil
+	}
+
+	if request.Debug != "" {
+		if !request.Explains() {
+			ctx.Error(echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid debug, supported values: %s",
+				entities.DebugExplain)))
+			return nil
+		}
+		if !h.isInternalCaller(ctx) {
+			ctx.Error(exceptions.NewUnauthorizedException("debug options are restricted to internal callers"))
+			return nil
+		}
+	}
+
+	if request.MinRating < 0 {
+		ctx.Error(echo.NewHTTPError(http.StatusBadRequest, "min_rating must not be negative"))
+		return nil
+	}
+
+	attributeFilters, err := entities.ParseAttributeFilters(request.Attributes)
+	if err != nil {
+		ctx.Error(echo.NewHTTPError(http.StatusBadRequest, err.Error()))
+		return nil
+	}
+	request.AttributeFilters = attributeFilters
+
+	now, err := h.evaluationTime(ctx.QueryParam(atParam), time.Now())
+	if err != nil {
+		h.logs.Error(str.ErrorConcat(err, handlerName, "CalculateDeliveryRange"))
+		ctx.Error(echo.NewHTTPError(http.StatusBadRequest, err.Error()))
+		return nil
+	}
+	request.Now = now
+
+	if err = h.checkCursor(*request, now); err != nil {
+		h.logs.Error(str.ErrorConcat(err, handlerName, "CalculateDeliveryRange"))
+		ctx.Error(echo.NewHTTPError(http.StatusBadRequest, err.Error()))
+		return nil
+	}
 
 	response, err := h.service.CalculateDeliveryRange(ctx.Request().Context(), *request)
 	if err != nil {
@@ -51,13 +125,248 @@ func (h *calculatorHandler) Calculate(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, response)
 }
 
+func (h *calculatorHandler) CalculateBatch(ctx echo.Context) error {
+	var locations []entities.BatchLocation
+	if err := ctx.Bind(&locations); err != nil {
+		h.logs.Error(str.ErrorConcat(err, handlerName, "CalculateBatch"))
+		ctx.Error(err)
+		return nil
+	}
+
+	if err := entities.ValidateBatchLocations(locations, h.config.Batch.MaxLocations); err != nil {
+		ctx.Error(echo.NewHTTPError(http.StatusBadRequest, err.Error()))
+		return nil
+	}
+
+	now := time.Now()
+	for i := range locations {
+		evaluationTime, err := h.evaluationTime(locations[i].At, now)
+		if err != nil {
+			h.logs.Error(str.ErrorConcat(err, handlerName, "CalculateBatch"))
+			ctx.Error(echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("location %s: %s", locations[i].ID, err)))
+			return nil
+		}
+		locations[i].Now = evaluationTime
+	}
+
+	response, err := h.service.CalculateBatchDeliveryRange(ctx.Request().Context(), locations)
+	if err != nil {
+		ctx.Error(err)
+		return nil
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (h *calculatorHandler) CheckDeliverability(ctx echo.Context) error {
+	request := new(entities.DeliverabilityRequest)
+	if err := ctx.Bind(request); err != nil {
+		h.logs.Error(str.ErrorConcat(err, handlerName, "CheckDeliverability"))
+		ctx.Error(err)
+		return nil
+	}
+
+	now, err := h.evaluationTime(ctx.QueryParam(atParam), time.Now())
+	if err != nil {
+		h.logs.Error(str.ErrorConcat(err, handlerName, "CheckDeliverability"))
+		ctx.Error(echo.NewHTTPError(http.StatusBadRequest, err.Error()))
+		return nil
+	}
+	request.Now = now
+
+	response, err := h.service.CheckDeliverability(ctx.Request().Context(), *request)
+	if err != nil {
+		ctx.Error(err)
+		return nil
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
 func (h *calculatorHandler) PreprocessRestaurants(ctx echo.Context) error {
-	err := h.service.PreprocessRestaurants(ctx.Request().Context())
+	var dryRun bool
+	if err := echo.QueryParamsBinder(ctx).Bool("dry_run", &dryRun).BindError(); err != nil {
+		h.logs.Error(str.ErrorConcat(err, handlerName, "PreprocessRestaurants"))
+		ctx.Error(echo.NewHTTPError(http.StatusBadRequest, err.Error()))
+		return nil
+	}
+
+	report, err := h.service.PreprocessRestaurants(ctx.Request().Context(), dryRun)
 	if err != nil {
 		ctx.Error(err)
 		return nil
 	}
-	h.logs.Info("Finish pre processing CSV data", fmt.Sprintf("%s.%s", handlerName, "CalculateDeliveryRange"))
+	h.logs.Info("Finish pre processing CSV data", fmt.Sprintf("%s.%s", handlerName, "PreprocessRestaurants"))
+
+	// the feed was read but had nothing usable, the previous dataset is still being served
+	if !report.DryRun && !report.Published {
+		return ctx.JSON(http.StatusUnprocessableEntity, report)
+	}
+
+	return ctx.JSON(http.StatusOK, report)
+}
+
+func (h *calculatorHandler) CreateOverride(ctx echo.Context) error {
+	if !h.isInternalCaller(ctx) {
+		ctx.Error(exceptions.NewUnauthorizedException("overrides are restricted to internal callers"))
+		return nil
+	}
+
+	override := new(entities.Override)
+	if err := ctx.Bind(override); err != nil {
+		h.logs.Error(str.ErrorConcat(err, handlerName, "CreateOverride"))
+		ctx.Error(err)
+		return nil
+	}
+
+	created, err := h.service.CreateOverride(ctx.Request().Context(), *override)
+	if err != nil {
+		ctx.Error(err)
+		return nil
+	}
+	h.logs.Info(fmt.Sprintf("Created override %s of restaurant %s from %s to %s: %s", created.ID,
+		created.RestaurantID, created.From, created.To, created.Hours),
+		fmt.Sprintf("%s.%s", handlerName, "CreateOverride"))
+
+	return ctx.JSON(http.StatusCreated, created)
+}
+
+func (h *calculatorHandler) ListOverrides(ctx echo.Context) error {
+	if !h.isInternalCaller(ctx) {
+		ctx.Error(exceptions.NewUnauthorizedException("overrides are restricted to internal callers"))
+		return nil
+	}
+
+	overrides, err := h.service.ListOverrides(ctx.Request().Context(), ctx.QueryParam(restaurantIDParam))
+	if err != nil {
+		ctx.Error(err)
+		return nil
+	}
+
+	return ctx.JSON(http.StatusOK, overrides)
+}
+
+func (h *calculatorHandler) DeleteOverride(ctx echo.Context) error {
+	if !h.isInternalCaller(ctx) {
+		ctx.Error(exceptions.NewUnauthorizedException("overrides are restricted to internal callers"))
+		return nil
+	}
+
+	id := ctx.Param("id")
+	if err := h.service.DeleteOverride(ctx.Request().Context(), id); err != nil {
+		ctx.Error(err)
+		return nil
+	}
+	h.logs.Info(fmt.Sprintf("Deleted override %s", id), fmt.Sprintf("%s.%s", handlerName, "DeleteOverride"))
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (h *calculatorHandler) CreateExclusion(ctx echo.Context) error {
+	if !h.isInternalCaller(ctx) {
+		ctx.Error(exceptions.NewUnauthorizedException("exclusions are restricted to internal callers"))
+		return nil
+	}
+
+	exclusion := new(entities.Exclusion)
+	if err := ctx.Bind(exclusion); err != nil {
+		h.logs.Error(str.ErrorConcat(err, handlerName, "CreateExclusion"))
+		ctx.Error(err)
+		return nil
+	}
+
+	created, err := h.service.CreateExclusion(ctx.Request().Context(), *exclusion)
+	if err != nil {
+		ctx.Error(err)
+		return nil
+	}
+	h.logs.Info(fmt.Sprintf("Created exclusion %s: %s", created.ID, created.Name),
+		fmt.Sprintf("%s.%s", handlerName, "CreateExclusion"))
+
+	return ctx.JSON(http.StatusCreated, created)
+}
+
+func (h *calculatorHandler) ListExclusions(ctx echo.Context) error {
+	if !h.isInternalCaller(ctx) {
+		ctx.Error(exceptions.NewUnauthorizedException("exclusions are restricted to internal callers"))
+		return nil
+	}
+
+	exclusions, err := h.service.ListExclusions(ctx.Request().Context())
+	if err != nil {
+		ctx.Error(err)
+		return nil
+	}
+
+	return ctx.JSON(http.StatusOK, exclusions)
+}
+
+func (h *calculatorHandler) DeleteExclusion(ctx echo.Context) error {
+	if !h.isInternalCaller(ctx) {
+		ctx.Error(exceptions.NewUnauthorizedException("exclusions are restricted to internal callers"))
+		return nil
+	}
+
+	id := ctx.Param("id")
+	if err := h.service.DeleteExclusion(ctx.Request().Context(), id); err != nil {
+		ctx.Error(err)
+		return nil
+	}
+	h.logs.Info(fmt.Sprintf("Deleted exclusion %s", id), fmt.Sprintf("%s.%s", handlerName, "DeleteExclusion"))
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// isInternalCaller checks the internal token header, no caller is internal when the token is not configured
+func (h *calculatorHandler) isInternalCaller(ctx echo.Context) bool {
+	token := ctx.Request().Header.Get(internalTokenHeader)
+	return h.config.InternalAPIToken != "" &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(h.config.InternalAPIToken)) == 1
+}
+
+// evaluationTime resolves the time availability is evaluated at: now, or the optional RFC 3339 at value for
+// scheduled orders, which must fall between now and the configured horizon
+func (h *calculatorHandler) evaluationTime(at string, now time.Time) (time.Time, error) {
+	if at == "" {
+		return now, nil
+	}
+
+	evaluationTime, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		return now, fmt.Errorf("invalid %s, expected an RFC 3339 time", atParam)
+	}
+
+	if evaluationTime.Before(now.Add(-pastTolerance)) {
+		return now, fmt.Errorf("%s must not be in the past", atParam)
+	}
+
+	if evaluationTime.After(now.Add(h.config.ScheduleHorizon)) {
+		return now, fmt.Errorf("%s must be within %s from now", atParam, h.config.ScheduleHorizon)
+	}
+
+	return evaluationTime, nil
+}
+
+// checkCursor rejects cursors evaluating later pages at a time the at parameter could not have set for the first
+// one, allowing CURSOR_TTL for the later pages to be requested. Cursors are not signed, the time in them can be
+// edited by the client.
+func (h *calculatorHandler) checkCursor(request entities.CalculationRequest, now time.Time) error {
+	if request.Cursor == "" {
+		return nil
+	}
+
+	resumed, err := request.ResumeCursor()
+	if err != nil {
+		return err
+	}
+
+	if resumed.Now.Before(now.Add(-pastTolerance - h.config.CursorTTL)) {
+		return fmt.Errorf("cursor expired, request the first page again")
+	}
+
+	if resumed.Now.After(now.Add(h.config.ScheduleHorizon)) {
+		return fmt.Errorf("cursor time must be within %s from now", h.config.ScheduleHorizon)
+	}
 
-	return ctx.NoContent(http.StatusOK)
+	return nil
 }
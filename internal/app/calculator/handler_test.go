@@ -1,16 +1,21 @@
 package calculator_test
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/sebastianreh/distance-calculator-api/internal/app/calculator"
 	"github.com/sebastianreh/distance-calculator-api/internal/config"
 	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/sebastianreh/distance-calculator-api/internal/entities/exceptions"
 	"github.com/sebastianreh/distance-calculator-api/test/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -69,6 +74,221 @@ func Test_CalculatorHandler_Calculate(t *testing.T) {
 		assert.Equal(t, http.StatusOK, recorder.Code)
 	})
 
+	t.Run("successful scheduled calculation", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+		at := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+		target := fmt.Sprintf("/calculate?lat=50.0534197&long=8.6705214&at=%s", url.QueryEscape(at.Format(time.RFC3339)))
+
+		ctx, recorder := setup(http.MethodGet, target, strings.NewReader(""))
+
+		serviceMock.On("CalculateDeliveryRange", ctx.Request().Context(),
+			mock.MatchedBy(func(request entities.CalculationRequest) bool {
+				return request.Now.Equal(at) && request.Lat == 50.0534197
+			})).Return(entities.CalculationResponse{RestaurantIDs: []string{"1"}}, nil)
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.Calculate(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		serviceMock.AssertExpectations(t)
+	})
+
+	t.Run("invalid scheduled time", func(t *testing.T) {
+		for _, at := range []string{
+			"tonight",
+			time.Now().Add(-time.Hour).Format(time.RFC3339),
+			time.Now().Add(cfg.ScheduleHorizon + time.Hour).Format(time.RFC3339),
+		} {
+			serviceMock := mocks.NewCalculatorServiceMock()
+			target := fmt.Sprintf("/calculate?lat=50.0534197&long=8.6705214&at=%s", url.QueryEscape(at))
+
+			ctx, recorder := setup(http.MethodGet, target, strings.NewReader(""))
+
+			handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+			err := handler.Calculate(ctx)
+
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, recorder.Code, at)
+			serviceMock.AssertNotCalled(t, "CalculateDeliveryRange", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("invalid expand", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+
+		ctx, recorder := setup(http.MethodGet, "/calculate?lat=50.0534197&long=8.6705214&expand=everything",
+			strings.NewReader(""))
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.Calculate(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("invalid sort and limit", func(t *testing.T) {
+		for _, query := range []string{"sort=name", "limit=-1", "limit=0", fmt.Sprintf("limit=%d", cfg.MaxPageSize+1)} {
+			serviceMock := mocks.NewCalculatorServiceMock()
+
+			ctx, recorder := setup(http.MethodGet, "/calculate?lat=50.0534197&long=8.6705214&"+query,
+				strings.NewReader(""))
+
+			handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+			err := handler.Calculate(ctx)
+
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, recorder.Code, query)
+		}
+	})
+
+	t.Run("cursor time bounds", func(t *testing.T) {
+		now := time.Now()
+		first := entities.CalculationRequest{Lat: 50.0534197, Long: 8.6705214, Limit: 1, Now: now}
+		_, cursor, err := first.Paginate([]entities.RestaurantDetails{{ID: "1", DistanceKm: 1},
+			{ID: "2", DistanceKm: 2}}, "v1")
+		require.NoError(t, err)
+		// cursors are plain base64 JSON, the client can edit the time later pages are evaluated at
+		tamper := func(evaluationTime time.Time) string {
+			cursorBytes, err := base64.RawURLEncoding.DecodeString(cursor)
+			require.NoError(t, err)
+			var fields map[string]interface{}
+			decoder := json.NewDecoder(strings.NewReader(string(cursorBytes)))
+			decoder.UseNumber()
+			require.NoError(t, decoder.Decode(&fields))
+			fields["t"] = evaluationTime
+			cursorBytes, err = json.Marshal(fields)
+			require.NoError(t, err)
+			return base64.RawURLEncoding.EncodeToString(cursorBytes)
+		}
+
+		tests := []struct {
+			name     string
+			cursor   string
+			expected int
+		}{
+			{"first page time", cursor, http.StatusOK},
+			{"within the cursor ttl", tamper(now.Add(-cfg.CursorTTL / 2)), http.StatusOK},
+			{"expired", tamper(now.Add(-cfg.CursorTTL - 2*time.Minute)), http.StatusBadRequest},
+			{"a year ago", tamper(now.AddDate(-1, 0, 0)), http.StatusBadRequest},
+			{"beyond the schedule horizon", tamper(now.Add(cfg.ScheduleHorizon + time.Hour)), http.StatusBadRequest},
+			{"not a cursor", "page-2", http.StatusBadRequest},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				serviceMock := mocks.NewCalculatorServiceMock()
+				ctx, recorder := setup(http.MethodGet, "/calculate?lat=50.0534197&long=8.6705214&limit=1&cursor="+
+					url.QueryEscape(test.cursor), strings.NewReader(""))
+				serviceMock.On("CalculateDeliveryRange", ctx.Request().Context(),
+					mock.AnythingOfType("entities.CalculationRequest")).Return(entities.CalculationResponse{}, nil)
+
+				handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+				err := handler.Calculate(ctx)
+
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, recorder.Code)
+				if test.expected != http.StatusOK {
+					serviceMock.AssertNotCalled(t, "CalculateDeliveryRange", mock.Anything, mock.Anything)
+				}
+			})
+		}
+	})
+
+	t.Run("rating and attribute filters", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+
+		ctx, recorder := setup(http.MethodGet,
+			"/calculate?lat=50.0534197&long=8.6705214&min_rating=4&attr=cuisine:italian&attr=price_tier:2",
+			strings.NewReader(""))
+
+		serviceMock.On("CalculateDeliveryRange", ctx.Request().Context(),
+			mock.MatchedBy(func(request entities.CalculationRequest) bool {
+				return request.MinRating == 4 && len(request.AttributeFilters) == 2 &&
+					request.AttributeFilters["cuisine"][0] == "italian"
+			})).Return(entities.CalculationResponse{RestaurantIDs: []string{"1"}}, nil)
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.Calculate(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		serviceMock.AssertExpectations(t)
+	})
+
+	t.Run("invalid filters", func(t *testing.T) {
+		for _, query := range []string{"min_rating=-1", "min_rating=high", "attr=italian", "attr=cuisine:"} {
+			serviceMock := mocks.NewCalculatorServiceMock()
+
+			ctx, recorder := setup(http.MethodGet, "/calculate?lat=50.0534197&long=8.6705214&"+query,
+				strings.NewReader(""))
+
+			handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+			err := handler.Calculate(ctx)
+
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, recorder.Code, query)
+		}
+	})
+
+	t.Run("explain for internal callers", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+		internalCfg := cfg
+		internalCfg.InternalAPIToken = "secret"
+
+		ctx, recorder := setup(http.MethodGet, "/calculate?lat=50.0534197&long=8.6705214&debug=explain",
+			strings.NewReader(""))
+		ctx.Request().Header.Set("X-Internal-Token", "secret")
+
+		serviceMock.On("CalculateDeliveryRange", ctx.Request().Context(),
+			mock.MatchedBy(func(request entities.CalculationRequest) bool {
+				return request.Explains()
+			})).Return(entities.CalculationResponse{RestaurantIDs: []string{}}, nil)
+
+		handler := calculator.NewCalculatorHandler(internalCfg, serviceMock, logs)
+		err := handler.Calculate(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		serviceMock.AssertExpectations(t)
+	})
+
+	t.Run("explain restricted to internal callers", func(t *testing.T) {
+		for name, tokens := range map[string][2]string{
+			"wrong token":          {"secret", "guess"},
+			"missing token":        {"secret", ""},
+			"token not configured": {"", ""},
+		} {
+			serviceMock := mocks.NewCalculatorServiceMock()
+			internalCfg := cfg
+			internalCfg.InternalAPIToken = tokens[0]
+
+			ctx, recorder := setup(http.MethodGet, "/calculate?lat=50.0534197&long=8.6705214&debug=explain",
+				strings.NewReader(""))
+			ctx.Request().Header.Set("X-Internal-Token", tokens[1])
+
+			handler := calculator.NewCalculatorHandler(internalCfg, serviceMock, logs)
+			err := handler.Calculate(ctx)
+
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusUnauthorized, recorder.Code, name)
+			serviceMock.AssertNotCalled(t, "CalculateDeliveryRange", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("invalid debug", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+
+		ctx, recorder := setup(http.MethodGet, "/calculate?lat=50.0534197&long=8.6705214&debug=trace",
+			strings.NewReader(""))
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.Calculate(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
 	t.Run("binding error", func(t *testing.T) {
 		serviceMock := mocks.NewCalculatorServiceMock()
 
@@ -109,6 +329,102 @@ func Test_CalculatorHandler_Calculate(t *testing.T) {
 	})
 }
 
+func Test_CalculatorHandler_CalculateBatch(t *testing.T) {
+	logs := logger.NewLogger()
+	cfg := config.NewConfig()
+	cfg.Batch.MaxLocations = 2
+
+	t.Run("successful batch calculation", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+		at := time.Now().Add(time.Hour).Truncate(time.Second)
+		body := fmt.Sprintf(`[{"id":"home","lat":50.05,"long":8.67},{"id":"office","lat":50.11,"long":8.68,"at":"%s"}]`,
+			at.Format(time.RFC3339))
+		response := entities.BatchCalculationResponse{Results: map[string]entities.BatchLocationResult{
+			"home": {RestaurantIDs: []string{"1"}}, "office": {RestaurantIDs: []string{}}}}
+
+		ctx, recorder := setup(http.MethodPost, "/calculate/restaurants/batch", strings.NewReader(body))
+
+		serviceMock.On("CalculateBatchDeliveryRange", ctx.Request().Context(),
+			mock.MatchedBy(func(locations []entities.BatchLocation) bool {
+				return len(locations) == 2 && locations[0].ID == "home" && !locations[0].Now.IsZero() &&
+					locations[1].Now.Equal(at)
+			})).Return(response, nil)
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.CalculateBatch(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"results":{"home":{"restaurant_ids":["1"]},"office":{"restaurant_ids":[]}}}`,
+			recorder.Body.String())
+		serviceMock.AssertExpectations(t)
+	})
+
+	t.Run("invalid batches", func(t *testing.T) {
+		for _, body := range []string{
+			`[]`,
+			`[{"id":"1"},{"id":"2"},{"id":"3"}]`,
+			`[{"id":"1"},{"id":"1"}]`,
+			`[{"lat":50.05,"long":8.67}]`,
+			`[{"id":"1","at":"tonight"}]`,
+			`{"id":"1"}`,
+		} {
+			serviceMock := mocks.NewCalculatorServiceMock()
+
+			ctx, recorder := setup(http.MethodPost, "/calculate/restaurants/batch", strings.NewReader(body))
+
+			handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+			err := handler.CalculateBatch(ctx)
+
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, recorder.Code, body)
+			serviceMock.AssertNotCalled(t, "CalculateBatchDeliveryRange", mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func Test_CalculatorHandler_CheckDeliverability(t *testing.T) {
+	logs := logger.NewLogger()
+	cfg := config.NewConfig()
+
+	t.Run("successful deliverability check", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+		response := entities.DeliverabilityResponse{RestaurantID: "42", Reason: entities.ReasonClosed}
+
+		ctx, recorder := setup(http.MethodGet, "/calculate/restaurants/42/deliverable?lat=50.0534197&long=8.6705214",
+			strings.NewReader(""))
+		setPathAndParams(ctx, []string{"id"}, []string{"42"}, "/calculate/restaurants/:id/deliverable")
+
+		serviceMock.On("CheckDeliverability", ctx.Request().Context(),
+			mock.MatchedBy(func(request entities.DeliverabilityRequest) bool {
+				return request.RestaurantID == "42" && request.Lat == 50.0534197 && !request.Now.IsZero()
+			})).Return(response, nil)
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.CheckDeliverability(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"restaurant_id":"42","deliverable":false,"reason":"closed"}`, recorder.Body.String())
+		serviceMock.AssertExpectations(t)
+	})
+
+	t.Run("invalid scheduled time", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+
+		ctx, recorder := setup(http.MethodGet, "/calculate/restaurants/42/deliverable?lat=50.05&long=8.67&at=tonight",
+			strings.NewReader(""))
+		setPathAndParams(ctx, []string{"id"}, []string{"42"}, "/calculate/restaurants/:id/deliverable")
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.CheckDeliverability(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		serviceMock.AssertNotCalled(t, "CheckDeliverability", mock.Anything, mock.Anything)
+	})
+}
+
 func Test_CalculatorHandler_PreprocessRestaurants(t *testing.T) {
 	logs := logger.NewLogger()
 	cfg := config.NewConfig()
@@ -118,13 +434,57 @@ func Test_CalculatorHandler_PreprocessRestaurants(t *testing.T) {
 
 		ctx, recorder := setup(http.MethodPost, "/preprocess", strings.NewReader(""))
 
-		serviceMock.On("PreprocessRestaurants", ctx.Request().Context()).Return(nil)
+		serviceMock.On("PreprocessRestaurants", ctx.Request().Context(), false).
+			Return(entities.PreprocessReport{Published: true, RowsAccepted: 10}, nil)
 
 		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
 		err := handler.PreprocessRestaurants(ctx)
 
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"rows_accepted":10`)
+	})
+
+	t.Run("successful dry run", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+
+		ctx, recorder := setup(http.MethodPost, "/preprocess?dry_run=true", strings.NewReader(""))
+
+		serviceMock.On("PreprocessRestaurants", ctx.Request().Context(), true).
+			Return(entities.PreprocessReport{DryRun: true, RowsAccepted: 10}, nil)
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.PreprocessRestaurants(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("invalid dry run param", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+
+		ctx, recorder := setup(http.MethodPost, "/preprocess?dry_run=maybe", strings.NewReader(""))
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.PreprocessRestaurants(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("nothing published", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+
+		ctx, recorder := setup(http.MethodPost, "/preprocess", strings.NewReader(""))
+
+		serviceMock.On("PreprocessRestaurants", ctx.Request().Context(), false).
+			Return(entities.PreprocessReport{RowsRejected: 10}, nil)
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.PreprocessRestaurants(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
 	})
 
 	t.Run("service error", func(t *testing.T) {
@@ -134,7 +494,8 @@ func Test_CalculatorHandler_PreprocessRestaurants(t *testing.T) {
 
 		ctx, recorder := setup(http.MethodPost, "/preprocess", strings.NewReader(""))
 
-		serviceMock.On("PreprocessRestaurants", ctx.Request().Context()).Return(expectedError)
+		serviceMock.On("PreprocessRestaurants", ctx.Request().Context(), false).
+			Return(entities.PreprocessReport{}, expectedError)
 
 		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
 		err := handler.PreprocessRestaurants(ctx)
@@ -143,3 +504,214 @@ func Test_CalculatorHandler_PreprocessRestaurants(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
 	})
 }
+
+func Test_CalculatorHandler_Overrides(t *testing.T) {
+	logs := logger.NewLogger()
+	cfg := config.NewConfig()
+	cfg.InternalAPIToken = "secret"
+	override := entities.Override{ID: "1", RestaurantID: "42", From: "2023-12-25", To: "2023-12-26", Hours: "closed",
+		Reason: "christmas"}
+
+	t.Run("successful creation", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+
+		ctx, recorder := setup(http.MethodPost, "/calculate/overrides",
+			strings.NewReader(`{"restaurant_id":"42","from":"2023-12-25","to":"2023-12-26","reason":"christmas"}`))
+		ctx.Request().Header.Set("X-Internal-Token", "secret")
+
+		serviceMock.On("CreateOverride", ctx.Request().Context(), entities.Override{RestaurantID: "42",
+			From: "2023-12-25", To: "2023-12-26", Reason: "christmas"}).Return(override, nil)
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.CreateOverride(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"hours":"closed"`)
+		serviceMock.AssertExpectations(t)
+	})
+
+	t.Run("invalid override", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+
+		ctx, recorder := setup(http.MethodPost, "/calculate/overrides", strings.NewReader(`{"restaurant_id":"42"}`))
+		ctx.Request().Header.Set("X-Internal-Token", "secret")
+
+		serviceMock.On("CreateOverride", ctx.Request().Context(), entities.Override{RestaurantID: "42"}).
+			Return(entities.Override{}, exceptions.NewBadRequestException("invalid from, expected a YYYY-MM-DD date"))
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.CreateOverride(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("list of a restaurant", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+
+		ctx, recorder := setup(http.MethodGet, "/calculate/overrides?restaurant_id=42", strings.NewReader(""))
+		ctx.Request().Header.Set("X-Internal-Token", "secret")
+
+		serviceMock.On("ListOverrides", ctx.Request().Context(), "42").Return([]entities.Override{override}, nil)
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.ListOverrides(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"reason":"christmas"`)
+	})
+
+	t.Run("successful deletion", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+
+		ctx, recorder := setup(http.MethodDelete, "/calculate/overrides/1", strings.NewReader(""))
+		ctx.Request().Header.Set("X-Internal-Token", "secret")
+		setPathAndParams(ctx, []string{"id"}, []string{"1"}, "/calculate/overrides/:id")
+
+		serviceMock.On("DeleteOverride", ctx.Request().Context(), "1").Return(nil)
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.DeleteOverride(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+	})
+
+	t.Run("unknown override", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+
+		ctx, recorder := setup(http.MethodDelete, "/calculate/overrides/7", strings.NewReader(""))
+		ctx.Request().Header.Set("X-Internal-Token", "secret")
+		setPathAndParams(ctx, []string{"id"}, []string{"7"}, "/calculate/overrides/:id")
+
+		serviceMock.On("DeleteOverride", ctx.Request().Context(), "7").
+			Return(exceptions.NewNotFoundException("override 7 not found"))
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.DeleteOverride(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("restricted to internal callers", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+
+		for name, call := range map[string]func(echo.Context) error{
+			"create": handler.CreateOverride,
+			"list":   handler.ListOverrides,
+			"delete": handler.DeleteOverride,
+		} {
+			ctx, recorder := setup(http.MethodGet, "/calculate/overrides", strings.NewReader(""))
+			ctx.Request().Header.Set("X-Internal-Token", "guess")
+
+			err := call(ctx)
+
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusUnauthorized, recorder.Code, name)
+		}
+		serviceMock.AssertNotCalled(t, "CreateOverride", mock.Anything, mock.Anything)
+		serviceMock.AssertNotCalled(t, "ListOverrides", mock.Anything, mock.Anything)
+		serviceMock.AssertNotCalled(t, "DeleteOverride", mock.Anything, mock.Anything)
+	})
+}
+
+func Test_CalculatorHandler_Exclusions(t *testing.T) {
+	logs := logger.NewLogger()
+	cfg := config.NewConfig()
+	cfg.InternalAPIToken = "secret"
+	airport := `{"name":"airport","zone":{"type":"Polygon","coordinates":` +
+		`[[[-0.49,51.46],[-0.42,51.46],[-0.42,51.48],[-0.49,51.48],[-0.49,51.46]]]}}`
+
+	t.Run("successful creation", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+
+		ctx, recorder := setup(http.MethodPost, "/calculate/exclusions", strings.NewReader(airport))
+		ctx.Request().Header.Set("X-Internal-Token", "secret")
+
+		serviceMock.On("CreateExclusion", ctx.Request().Context(), mock.MatchedBy(func(exclusion entities.Exclusion) bool {
+			return exclusion.Name == "airport" && exclusion.Zone.Contains(51.47, -0.45)
+		})).Return(entities.Exclusion{ID: "1", Name: "airport"}, nil)
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.CreateExclusion(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"id":"1"`)
+		serviceMock.AssertExpectations(t)
+	})
+
+	t.Run("invalid zone", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+
+		ctx, recorder := setup(http.MethodPost, "/calculate/exclusions",
+			strings.NewReader(`{"name":"airport","zone":{"type":"Point","coordinates":[-0.45,51.47]}}`))
+		ctx.Request().Header.Set("X-Internal-Token", "secret")
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.CreateExclusion(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		serviceMock.AssertNotCalled(t, "CreateExclusion", mock.Anything, mock.Anything)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+
+		ctx, recorder := setup(http.MethodGet, "/calculate/exclusions", strings.NewReader(""))
+		ctx.Request().Header.Set("X-Internal-Token", "secret")
+
+		serviceMock.On("ListExclusions", ctx.Request().Context()).
+			Return(entities.Exclusions{{ID: "1", Name: "airport"}}, nil)
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.ListExclusions(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"name":"airport"`)
+	})
+
+	t.Run("unknown exclusion", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+
+		ctx, recorder := setup(http.MethodDelete, "/calculate/exclusions/7", strings.NewReader(""))
+		ctx.Request().Header.Set("X-Internal-Token", "secret")
+		setPathAndParams(ctx, []string{"id"}, []string{"7"}, "/calculate/exclusions/:id")
+
+		serviceMock.On("DeleteExclusion", ctx.Request().Context(), "7").
+			Return(exceptions.NewNotFoundException("exclusion 7 not found"))
+
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+		err := handler.DeleteExclusion(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("restricted to internal callers", func(t *testing.T) {
+		serviceMock := mocks.NewCalculatorServiceMock()
+		handler := calculator.NewCalculatorHandler(cfg, serviceMock, logs)
+
+		for name, call := range map[string]func(echo.Context) error{
+			"create": handler.CreateExclusion,
+			"list":   handler.ListExclusions,
+			"delete": handler.DeleteExclusion,
+		} {
+			ctx, recorder := setup(http.MethodGet, "/calculate/exclusions", strings.NewReader(""))
+
+			err := call(ctx)
+
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusUnauthorized, recorder.Code, name)
+		}
+		serviceMock.AssertNotCalled(t, "CreateExclusion", mock.Anything, mock.Anything)
+		serviceMock.AssertNotCalled(t, "ListExclusions", mock.Anything)
+		serviceMock.AssertNotCalled(t, "DeleteExclusion", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,173 @@
+package calculator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/sebastianreh/distance-calculator-api/internal/config"
+	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/sebastianreh/distance-calculator-api/pkg/geoindex"
+	"github.com/sebastianreh/distance-calculator-api/pkg/logger"
+	"github.com/sebastianreh/distance-calculator-api/pkg/redis"
+)
+
+const (
+	memoryRepositoryName = "calculator.memory_repository"
+	BackendRedis         = "redis"
+	BackendMemory        = "memory"
+)
+
+// memorySnapshot is a dataset version loaded in memory, with its restaurants indexed by location
+type memorySnapshot struct {
+	version       string
+	timeRadiusMap entities.TimeRadiusMap
+	index         *geoindex.Grid
+}
+
+// snapshotLoad is the load of a version's snapshot, done is closed once it finished
+type snapshotLoad struct {
+	done     chan struct{}
+	snapshot *memorySnapshot
+	err      error
+}
+
+// memoryCalculatorRepository serves reads from an in-process spatial index of the current dataset. Writes and
+// the current dataset pointer go to the wrapped repository, which stays the source of truth shared by every
+// replica; a version is loaded from its time radius map, which holds every restaurant location, the first
+// time it is read. The previous version is kept too, so requests still on it while replicas switch to a new
+// one do not evict it.
+type memoryCalculatorRepository struct {
+	CalculatorRepository
+	logs     logger.Logger
+	mutex    sync.RWMutex
+	current  *memorySnapshot
+	previous *memorySnapshot
+	// loads in flight by version, so a version is only loaded once however many requests need it
+	loading map[string]*snapshotLoad
+}
+
+// NewCalculatorRepositoryForBackend builds the repository selected by config.RepositoryBackend
+func NewCalculatorRepositoryForBackend(cfg config.Config, rds redis.Redis, logs logger.Logger) (CalculatorRepository,
+	error) {
+	switch cfg.RepositoryBackend {
+	case BackendRedis:
+		return NewCalculatorRepository(cfg, rds, logs), nil
+	case BackendMemory:
+		return NewMemoryCalculatorRepository(NewCalculatorRepository(cfg, rds, logs), logs), nil
+	default:
+		return nil, fmt.Errorf("unknown repository backend %q", cfg.RepositoryBackend)
+	}
+}
+
+func NewMemoryCalculatorRepository(repository CalculatorRepository, logs logger.Logger) CalculatorRepository {
+	return &memoryCalculatorRepository{
+		CalculatorRepository: repository,
+		logs:                 logs,
+	}
+}
+
+func (r *memoryCalculatorRepository) GetTimeRadiusMapData(ctx context.Context,
+	version string) (entities.TimeRadiusMap, error) {
+	snapshot, err := r.load(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot.timeRadiusMap, nil
+}
+
+func (r *memoryCalculatorRepository) GetRestaurantsInRadius(ctx context.Context, version string, lat, long,
+	radius float64) ([]entities.RestaurantIDLatLng, error) {
+	var restaurants []entities.RestaurantIDLatLng
+	snapshot, err := r.load(ctx, version)
+	if err != nil {
+		return restaurants, err
+	}
+
+	for _, point := range snapshot.index.Search(lat, long, radius) {
+		restaurants = append(restaurants, entities.RestaurantIDLatLng{
+			ID:             point.ID,
+			Lat:            point.Lat,
+			Long:           point.Long,
+			DeliveryRadius: snapshot.timeRadiusMap[point.ID].Radius,
+		})
+	}
+
+	return restaurants, nil
+}
+
+// load returns the snapshot of the version, loading it from the wrapped repository when it is not in memory.
+// Loads run without holding the lock, so requests on the versions in memory are never stalled by them.
+func (r *memoryCalculatorRepository) load(ctx context.Context, version string) (*memorySnapshot, error) {
+	r.mutex.RLock()
+	snapshot := r.snapshotOf(version)
+	r.mutex.RUnlock()
+	if snapshot != nil {
+		return snapshot, nil
+	}
+
+	r.mutex.Lock()
+	if snapshot = r.snapshotOf(version); snapshot != nil {
+		r.mutex.Unlock()
+		return snapshot, nil
+	}
+	load, inFlight := r.loading[version]
+	if !inFlight {
+		load = &snapshotLoad{done: make(chan struct{})}
+		if r.loading == nil {
+			r.loading = make(map[string]*snapshotLoad)
+		}
+		r.loading[version] = load
+	}
+	r.mutex.Unlock()
+
+	if inFlight {
+		select {
+		case <-load.done:
+			return load.snapshot, load.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	timeRadiusMap, err := r.CalculatorRepository.GetTimeRadiusMapData(ctx, version)
+	if err == nil {
+		load.snapshot = &memorySnapshot{
+			version:       version,
+			timeRadiusMap: timeRadiusMap,
+			index:         geoindex.NewGrid(timeRadiusMapPoints(timeRadiusMap)),
+		}
+		r.logs.Info(fmt.Sprintf("Loaded dataset %s with %d restaurants", version, load.snapshot.index.Len()),
+			fmt.Sprintf("%s.%s", memoryRepositoryName, "load"))
+	}
+	load.err = err
+
+	r.mutex.Lock()
+	delete(r.loading, version)
+	if load.err == nil {
+		r.previous, r.current = r.current, load.snapshot
+	}
+	r.mutex.Unlock()
+	close(load.done)
+
+	return load.snapshot, load.err
+}
+
+// snapshotOf returns the snapshot of the version when it is in memory. It must be called holding the lock.
+func (r *memoryCalculatorRepository) snapshotOf(version string) *memorySnapshot {
+	for _, snapshot := range []*memorySnapshot{r.current, r.previous} {
+		if snapshot != nil && snapshot.version == version {
+			return snapshot
+		}
+	}
+	return nil
+}
+
+func timeRadiusMapPoints(timeRadiusMap entities.TimeRadiusMap) []geoindex.Point {
+	points := make([]geoindex.Point, 0, len(timeRadiusMap))
+	for id, timeRadius := range timeRadiusMap {
+		points = append(points, geoindex.Point{ID: id, Lat: timeRadius.Lat, Long: timeRadius.Long})
+	}
+	return points
+}
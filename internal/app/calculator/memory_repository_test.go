@@ -0,0 +1,200 @@
+package calculator_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/internal/app/calculator"
+	"github.com/sebastianreh/distance-calculator-api/internal/config"
+	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/sebastianreh/distance-calculator-api/pkg/logger"
+	"github.com/sebastianreh/distance-calculator-api/pkg/redis"
+	"github.com/sebastianreh/distance-calculator-api/pkg/schedule"
+	"github.com/sebastianreh/distance-calculator-api/test/mocks"
+	"github.com/sebastianreh/distance-calculator-api/test/standin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const benchmarkRestaurants = 10000
+
+func Test_MemoryCalculatorRepository(t *testing.T) {
+	logs := logger.NewLogger()
+	ctx := context.Background()
+	allDay := schedule.NewWeek(schedule.Day{Intervals: []schedule.Interval{schedule.AllDay}})
+	firstMap := entities.Restaurants{
+		{ID: "1", Lat: 51.5, Long: -0.1, Radius: 5, Schedule: allDay},
+		{ID: "2", Lat: 51.6, Long: -0.2, Radius: 3, Schedule: allDay},
+	}.CreateTimeRadiusMap()
+	secondMap := entities.Restaurants{
+		{ID: "3", Lat: 51.5, Long: -0.1, Radius: 4, Schedule: allDay},
+	}.CreateTimeRadiusMap()
+
+	t.Run("loads each version once from the wrapped repository", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("GetTimeRadiusMapData", ctx, "1").Return(firstMap, nil).Once()
+		repositoryMock.On("GetTimeRadiusMapData", ctx, "2").Return(secondMap, nil).Once()
+		repository := calculator.NewMemoryCalculatorRepository(repositoryMock, logs)
+
+		restaurants, err := repository.GetRestaurantsInRadius(ctx, "1", 51.5, -0.1, 6)
+		assert.NoError(t, err)
+		assert.Equal(t, []entities.RestaurantIDLatLng{{ID: "1", Lat: 51.5, Long: -0.1, DeliveryRadius: 5}}, restaurants)
+
+		timeRadiusMap, err := repository.GetTimeRadiusMapData(ctx, "1")
+		assert.NoError(t, err)
+		assert.Equal(t, firstMap, timeRadiusMap)
+
+		restaurants, err = repository.GetRestaurantsInRadius(ctx, "2", 51.5, -0.1, 6)
+		assert.NoError(t, err)
+		assert.Equal(t, []entities.RestaurantIDLatLng{{ID: "3", Lat: 51.5, Long: -0.1, DeliveryRadius: 4}}, restaurants)
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("versions queried alternately are loaded once each", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("GetTimeRadiusMapData", ctx, "1").Return(firstMap, nil).Once()
+		repositoryMock.On("GetTimeRadiusMapData", ctx, "2").Return(secondMap, nil).Once()
+		repository := calculator.NewMemoryCalculatorRepository(repositoryMock, logs)
+
+		for i := 0; i < 3; i++ {
+			for _, version := range []string{"1", "2"} {
+				restaurants, err := repository.GetRestaurantsInRadius(ctx, version, 51.5, -0.1, 6)
+				assert.NoError(t, err)
+				assert.Len(t, restaurants, 1)
+			}
+		}
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("concurrent requests share a load", func(t *testing.T) {
+		loaded := make(chan struct{})
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("GetTimeRadiusMapData", ctx, "1").Return(firstMap, nil).Once().
+			Run(func(mock.Arguments) { <-loaded })
+		repository := calculator.NewMemoryCalculatorRepository(repositoryMock, logs)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				restaurants, err := repository.GetRestaurantsInRadius(ctx, "1", 51.5, -0.1, 6)
+				assert.NoError(t, err)
+				assert.Len(t, restaurants, 1)
+			}()
+		}
+		close(loaded)
+		wg.Wait()
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("writes and dataset pointer go to the wrapped repository", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		dataset := entities.Dataset{Version: "1"}
+		repositoryMock.On("GetCurrentDataset", ctx).Return(dataset, nil)
+		repositoryMock.On("SetTimeRadiusMapData", ctx, "1", firstMap).Return(nil)
+		repository := calculator.NewMemoryCalculatorRepository(repositoryMock, logs)
+
+		current, err := repository.GetCurrentDataset(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, dataset, current)
+		assert.NoError(t, repository.SetTimeRadiusMapData(ctx, "1", firstMap))
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("load error", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("GetTimeRadiusMapData", ctx, "1").Return(entities.TimeRadiusMap(nil), assert.AnError)
+		repository := calculator.NewMemoryCalculatorRepository(repositoryMock, logs)
+
+		_, err := repository.GetRestaurantsInRadius(ctx, "1", 51.5, -0.1, 6)
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("unknown backend", func(t *testing.T) {
+		cfg := config.Config{RepositoryBackend: "postgres"}
+
+		_, err := calculator.NewCalculatorRepositoryForBackend(cfg, nil, logs)
+		assert.Error(t, err)
+	})
+}
+
+// benchmarkCSV spreads the restaurants over a 20 km x 20 km area of London
+func benchmarkCSV(count int) string {
+	var csv strings.Builder
+	csv.WriteString("id,latitude,longitude,availability_radius,open_hour,close_hour,rating\n")
+	for i := 0; i < count; i++ {
+		csv.WriteString(fmt.Sprintf("restaurant-%d,%f,%f,%d,00:00,00:00,4.5\n", i,
+			51.42+float64(i%100)*0.0018, -0.26+float64(i/100)*0.0029, 1+i%6))
+	}
+	return csv.String()
+}
+
+// setupBenchmarkService preprocesses the benchmark restaurants into a Redis stand-in and returns a service
+// reading them through the given backend
+func setupBenchmarkService(b *testing.B, backend string) calculator.CalculatorService {
+	ctx := context.Background()
+	logs := logger.NewLogger()
+	server, err := standin.NewRedisServer()
+	require.NoError(b, err)
+	b.Cleanup(func() { _ = server.Close() })
+
+	cfg := config.NewConfig()
+	cfg.Redis.Host = server.Addr()
+	cfg.RepositoryBackend = backend
+	cfg.Preprocess.ProgressInterval = 0
+	client, err := redis.NewRedis(logs, cfg)
+	require.NoError(b, err)
+
+	repository, err := calculator.NewCalculatorRepositoryForBackend(cfg, client, logs)
+	require.NoError(b, err)
+	sourceMock := mocks.NewRestaurantSourceMock()
+	sourceMock.On("Open", ctx).Return(benchmarkCSV(benchmarkRestaurants), nil)
+
+	service := calculator.NewCalculatorService(cfg, repository, sourceMock, nil, logs)
+	report, err := service.PreprocessRestaurants(ctx, false)
+	require.NoError(b, err)
+	require.True(b, report.Published)
+
+	return service
+}
+
+// BenchmarkCalculatorService_CalculateDeliveryRange compares the Redis and in-memory backends with as many
+// concurrent users as the load tests in files/benchmarks
+func BenchmarkCalculatorService_CalculateDeliveryRange(b *testing.B) {
+	ctx := context.Background()
+
+	for _, backend := range []string{calculator.BackendRedis, calculator.BackendMemory} {
+		service := setupBenchmarkService(b, backend)
+
+		for _, users := range []int{1, 10, 50, 100} {
+			b.Run(fmt.Sprintf("%s/%d-users", backend, users), func(b *testing.B) {
+				var requests int64
+				var wg sync.WaitGroup
+
+				b.ResetTimer()
+				for user := 0; user < users; user++ {
+					wg.Add(1)
+					go func(user int) {
+						defer wg.Done()
+						for i := atomic.AddInt64(&requests, 1); i <= int64(b.N); i = atomic.AddInt64(&requests, 1) {
+							request := entities.CalculationRequest{Now: time.Now(),
+								Lat: 51.42 + float64(i%97)*0.0018, Long: -0.26 + float64(user%89)*0.0029}
+							if _, err := service.CalculateDeliveryRange(ctx, request); err != nil {
+								b.Error(err)
+								return
+							}
+						}
+					}(user)
+				}
+				wg.Wait()
+			})
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package calculator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/sebastianreh/distance-calculator-api/internal/entities/exceptions"
+)
+
+// currentAvailability returns the current dataset with its time radius map and the overrides to apply to it
+func (r *calculatorService) currentAvailability(ctx context.Context) (entities.Dataset, entities.TimeRadiusMap,
+	entities.Overrides, error) {
+	dataset, timeRadiusMap, err := r.currentDataset(ctx)
+	if err != nil || dataset.IsEmpty() {
+		return dataset, timeRadiusMap, nil, err
+	}
+
+	overrides, err := r.currentOverrides(ctx)
+	if err != nil {
+		return dataset, nil, nil, err
+	}
+
+	return dataset, timeRadiusMap, overrides, nil
+}
+
+// currentOverrides returns the overrides of every restaurant, loaded again once OVERRIDES_REFRESH_INTERVAL has
+// passed, or right after this replica changed them
+func (r *calculatorService) currentOverrides(ctx context.Context) (entities.Overrides, error) {
+	return r.overrides.get(ctx, r.config.OverridesRefreshInterval, func(ctx context.Context) (entities.Overrides,
+		error) {
+		overrides, err := r.repository.GetOverrides(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return entities.NewOverrides(overrides), nil
+	})
+}
+
+// CreateOverride stores a closure or special hours of a restaurant, applied by every replica once they load
+// the overrides again
+func (r *calculatorService) CreateOverride(ctx context.Context, override entities.Override) (entities.Override,
+	error) {
+	override, err := entities.NewOverride(override, time.Now())
+	if err != nil {
+		return override, exceptions.NewBadRequestException(err.Error())
+	}
+
+	err = r.repository.SetOverride(ctx, override)
+	if err != nil {
+		return override, err
+	}
+	r.overrides.invalidate()
+
+	return override, nil
+}
+
+// ListOverrides returns the overrides of the restaurant, or of every restaurant when restaurantID is empty,
+// ordered by start date
+func (r *calculatorService) ListOverrides(ctx context.Context, restaurantID string) ([]entities.Override, error) {
+	overrides, err := r.repository.GetOverrides(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	listed := make([]entities.Override, 0, len(overrides))
+	for _, override := range overrides {
+		if restaurantID == "" || override.RestaurantID == restaurantID {
+			listed = append(listed, override)
+		}
+	}
+	sort.Slice(listed, func(i, j int) bool {
+		if listed[i].From != listed[j].From {
+			return listed[i].From < listed[j].From
+		}
+		return listed[i].CreatedAt.Before(listed[j].CreatedAt)
+	})
+
+	return listed, nil
+}
+
+func (r *calculatorService) DeleteOverride(ctx context.Context, id string) error {
+	deleted, err := r.repository.DeleteOverride(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return exceptions.NewNotFoundException(fmt.Sprintf("override %s not found", id))
+	}
+	r.overrides.invalidate()
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package calculator
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// refreshCache keeps a value loaded from the repository, served from memory until its refresh interval passes.
+// Once stale a single request loads it again without holding the lock, the others keep being served the stale
+// value meanwhile and only wait when nothing was loaded yet.
+type refreshCache[T any] struct {
+	mutex       sync.RWMutex
+	value       T
+	loaded      bool
+	refreshedAt time.Time
+	// invalidations so far, a load started before the latest one does not make the value fresh
+	generation int
+	refresh    *valueLoad[T]
+}
+
+// valueLoad is the load of a refreshCache value, done is closed once it finished
+type valueLoad[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// fresh tells if the value can be served without loading it again. It must be called holding the lock.
+func (c *refreshCache[T]) fresh(refreshInterval time.Duration) bool {
+	return !c.refreshedAt.IsZero() && time.Since(c.refreshedAt) < refreshInterval
+}
+
+// invalidate makes the next request load the value again
+func (c *refreshCache[T]) invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.refreshedAt = time.Time{}
+	c.generation++
+}
+
+// get returns the value, loading it with load once refreshInterval has passed or after it was invalidated
+func (c *refreshCache[T]) get(ctx context.Context, refreshInterval time.Duration,
+	load func(ctx context.Context) (T, error)) (T, error) {
+	c.mutex.RLock()
+	if c.fresh(refreshInterval) {
+		value := c.value
+		c.mutex.RUnlock()
+		return value, nil
+	}
+	c.mutex.RUnlock()
+
+	c.mutex.Lock()
+	if c.fresh(refreshInterval) || (c.refresh != nil && c.loaded) {
+		value := c.value
+		c.mutex.Unlock()
+		return value, nil
+	}
+	refresh, inFlight := c.refresh, c.refresh != nil
+	if !inFlight {
+		refresh = &valueLoad[T]{done: make(chan struct{})}
+		c.refresh = refresh
+	}
+	generation := c.generation
+	c.mutex.Unlock()
+
+	if inFlight {
+		select {
+		case <-refresh.done:
+			return refresh.value, refresh.err
+		case <-ctx.Done():
+			var value T
+			return value, ctx.Err()
+		}
+	}
+
+	refresh.value, refresh.err = load(ctx)
+
+	c.mutex.Lock()
+	c.refresh = nil
+	if refresh.err == nil {
+		c.value, c.loaded = refresh.value, true
+		if c.generation == generation {
+			c.refreshedAt = time.Now()
+		}
+	}
+	c.mutex.Unlock()
+	close(refresh.done)
+
+	return refresh.value, refresh.err
+}
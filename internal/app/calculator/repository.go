@@ -2,9 +2,7 @@ package calculator
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
 
@@ -17,21 +15,36 @@ import (
 )
 
 const (
-	repositoryName         = "calculator.repository"
-	timeRadiusMapKey       = "restaurants:time_radius_map"
-	restaurantsGeoDataKey  = "restaurants:geodata"
+	repositoryName = "calculator.repository"
+	// hash of the attributes of every restaurant, by id
+	restaurantAttributesKey = "restaurants:attributes"
+	restaurantsGeoDataKey   = "restaurants:geodata"
+	currentDatasetKey       = "restaurants:current_dataset"
+	datasetVersionsKey      = "restaurants:dataset_versions"
+	datasetsChannel         = "restaurants:datasets"
+	// hash of the delivery exclusion zones, by exclusion id
+	exclusionsKey = "restaurants:exclusions"
+	// hash of the schedule overrides of every restaurant, by override id. Overrides outlive datasets.
+	overridesKey           = "restaurants:overrides"
 	InactiveTimeTTL        = time.Duration(12)*time.Hour + time.Duration(30)*time.Minute
 	invalidLatLongRedisErr = "ERR invalid longitude,latitude pair"
-	keySeparator           = "-"
-	keyParts               = 4
-	bitSize                = 64
 )
 
 type CalculatorRepository interface {
-	SetTimeRadiusMapData(ctx context.Context, timeRadiusMap entities.TimeRadiusMap) error
-	GetTimeRadiusMapData(ctx context.Context) (entities.TimeRadiusMap, error)
-	SetRestaurantGeoData(ctx context.Context, restaurants entities.Restaurants) error
-	GetRestaurantsInRadius(ctx context.Context, lat, long, radius float64) ([]entities.RestaurantIDLatLng, error)
+	RegisterDataset(ctx context.Context, dataset entities.Dataset) error
+	PublishDataset(ctx context.Context, dataset entities.Dataset) error
+	GetCurrentDataset(ctx context.Context) (entities.Dataset, error)
+	WatchDatasets(ctx context.Context) (<-chan entities.Dataset, error)
+	SetTimeRadiusMapData(ctx context.Context, version string, timeRadiusMap entities.TimeRadiusMap) error
+	GetTimeRadiusMapData(ctx context.Context, version string) (entities.TimeRadiusMap, error)
+	SetRestaurantGeoData(ctx context.Context, version string, restaurants entities.Restaurants) error
+	GetRestaurantsInRadius(ctx context.Context, version string, lat, long, radius float64) ([]entities.RestaurantIDLatLng, error)
+	SetOverride(ctx context.Context, override entities.Override) error
+	GetOverrides(ctx context.Context) ([]entities.Override, error)
+	DeleteOverride(ctx context.Context, id string) (bool, error)
+	SetExclusion(ctx context.Context, exclusion entities.Exclusion) error
+	GetExclusions(ctx context.Context) (entities.Exclusions, error)
+	DeleteExclusion(ctx context.Context, id string) (bool, error)
 }
 
 type calculatorRepository struct {
@@ -50,46 +63,195 @@ func NewCalculatorRepository(cfg config.Config, rds redis.Redis, logs logger.Log
 	}
 }
 
-func (r *calculatorRepository) SetTimeRadiusMapData(ctx context.Context, timeRadiusMap entities.TimeRadiusMap) error {
-	timeRadiusMapBytes, _ := r.json.Marshal(timeRadiusMap)
+// RegisterDataset records a version before any of its keys is written, so the keys of a preprocess that
+// never gets published are still garbage collected afterwards
+func (r *calculatorRepository) RegisterDataset(ctx context.Context, dataset entities.Dataset) error {
+	err := r.redis.ZAdd(ctx, datasetVersionsKey, dataset.Version, float64(dataset.CreatedAt.UnixMilli()))
+	if err != nil {
+		r.logs.Error(str.ErrorConcat(err, repositoryName, "RegisterDataset"))
+		return err
+	}
 
-	err := r.redis.Set(ctx, timeRadiusMapKey, string(timeRadiusMapBytes), InactiveTimeTTL)
+	return nil
+}
+
+// PublishDataset atomically switches the current dataset pointer to the given version, notifies the replicas
+// watching datasets and then removes older versions. The previously published version is kept so in-flight
+// requests can still finish.
+func (r *calculatorRepository) PublishDataset(ctx context.Context, dataset entities.Dataset) error {
+	previous, err := r.GetCurrentDataset(ctx)
 	if err != nil {
-		r.logs.Error(str.ErrorConcat(err, repositoryName, "SetTimeRadiusMapData"))
 		return err
 	}
 
+	err = r.redis.Expire(ctx, versionedKey(restaurantsGeoDataKey, dataset.Version), InactiveTimeTTL)
+	if err != nil {
+		r.logs.Error(str.ErrorConcat(err, repositoryName, "PublishDataset"))
+		return err
+	}
+
+	datasetBytes, _ := r.json.Marshal(dataset)
+	err = r.redis.Set(ctx, currentDatasetKey, string(datasetBytes), InactiveTimeTTL)
+	if err != nil {
+		r.logs.Error(str.ErrorConcat(err, repositoryName, "PublishDataset"))
+		return err
+	}
+
+	// replicas missing the notification still pick the dataset up when they refresh the pointer
+	err = r.redis.Publish(ctx, datasetsChannel, string(datasetBytes))
+	if err != nil {
+		r.logs.Warn(str.ErrorConcat(err, repositoryName, "PublishDataset"))
+	}
+
+	r.collectOldDatasets(ctx, dataset, previous)
+
 	return nil
 }
 
-func (r *calculatorRepository) GetTimeRadiusMapData(ctx context.Context) (entities.TimeRadiusMap, error) {
-	var timeRadiusMap entities.TimeRadiusMap
-	timeRadiusMapString, err := r.redis.Get(ctx, timeRadiusMapKey)
+// collectOldDatasets deletes every version older than the published one except the previous one. Versions
+// registered later belong to a preprocess still running and are left alone. Failures are only logged since
+// the new dataset is already live.
+func (r *calculatorRepository) collectOldDatasets(ctx context.Context, published, previous entities.Dataset) {
+	versions, err := r.redis.ZRange(ctx, datasetVersionsKey)
 	if err != nil {
-		r.logs.Error(str.ErrorConcat(err, repositoryName, "GetCoordinatesData"))
-		return timeRadiusMap, err
+		r.logs.Warn(str.ErrorConcat(err, repositoryName, "collectOldDatasets"))
+		return
 	}
 
-	_ = r.json.Unmarshal([]byte(timeRadiusMapString), &timeRadiusMap)
+	for _, version := range versions {
+		if version == published.Version {
+			break
+		}
+		if version == previous.Version {
+			continue
+		}
 
-	return timeRadiusMap, nil
+		err = r.redis.Del(ctx, versionedKey(restaurantsGeoDataKey, version), versionedKey(restaurantAttributesKey, version))
+		if err == nil {
+			err = r.redis.ZRem(ctx, datasetVersionsKey, version)
+		}
+		if err != nil {
+			r.logs.Warn(str.ErrorConcat(err, repositoryName, "collectOldDatasets"))
+		}
+	}
 }
 
-func (r *calculatorRepository) SetRestaurantGeoData(ctx context.Context, restaurants entities.Restaurants) error {
-	for _, restaurant := range restaurants {
-		err := r.redis.GeoAdd(ctx, restaurantsGeoDataKey, restaurant.ID, restaurant.Lat, restaurant.Long, restaurant.Radius)
+func (r *calculatorRepository) GetCurrentDataset(ctx context.Context) (entities.Dataset, error) {
+	var dataset entities.Dataset
+	datasetString, err := r.redis.Get(ctx, currentDatasetKey)
+	if err != nil {
+		r.logs.Error(str.ErrorConcat(err, repositoryName, "GetCurrentDataset"))
+		return dataset, err
+	}
+
+	_ = r.json.Unmarshal([]byte(datasetString), &dataset)
+
+	return dataset, nil
+}
+
+// WatchDatasets streams every dataset published from now on, by any replica, until ctx is done
+func (r *calculatorRepository) WatchDatasets(ctx context.Context) (<-chan entities.Dataset, error) {
+	messages, err := r.redis.Subscribe(ctx, datasetsChannel)
+	if err != nil {
+		r.logs.Error(str.ErrorConcat(err, repositoryName, "WatchDatasets"))
+		return nil, err
+	}
+
+	datasets := make(chan entities.Dataset)
+	go func() {
+		defer close(datasets)
+		for message := range messages {
+			var dataset entities.Dataset
+			if err := r.json.Unmarshal([]byte(message), &dataset); err != nil || dataset.IsEmpty() {
+				r.logs.Warn(fmt.Sprintf("ignoring invalid dataset notification %q", message),
+					fmt.Sprintf("%s.%s", repositoryName, "WatchDatasets"))
+				continue
+			}
+			select {
+			case datasets <- dataset:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return datasets, nil
+}
+
+// SetTimeRadiusMapData adds the attributes of each restaurant of the map, one preprocessing batch, as fields of
+// the version's attributes hash
+func (r *calculatorRepository) SetTimeRadiusMapData(ctx context.Context, version string,
+	timeRadiusMap entities.TimeRadiusMap) error {
+	key := versionedKey(restaurantAttributesKey, version)
+	fields := make(map[string]string, len(timeRadiusMap))
+	for id := range timeRadiusMap {
+		entry, err := timeRadiusMap.EncodeEntry(id)
 		if err != nil {
-			r.logs.Error(str.ErrorConcat(err, repositoryName, "SetRestaurantGeoData"))
+			r.logs.Error(str.ErrorConcat(err, repositoryName, "SetTimeRadiusMapData"))
 			return err
 		}
+		fields[id] = string(entry)
+	}
+
+	err := r.redis.HSetBulk(ctx, key, fields)
+	if err != nil {
+		r.logs.Error(str.ErrorConcat(err, repositoryName, "SetTimeRadiusMapData"))
+		return err
+	}
+
+	err = r.redis.Expire(ctx, key, InactiveTimeTTL)
+	if err != nil {
+		r.logs.Error(str.ErrorConcat(err, repositoryName, "SetTimeRadiusMapData"))
+		return err
+	}
+
+	return nil
+}
+
+func (r *calculatorRepository) GetTimeRadiusMapData(ctx context.Context, version string) (entities.TimeRadiusMap, error) {
+	fields, err := r.redis.HGetAll(ctx, versionedKey(restaurantAttributesKey, version))
+	if err != nil {
+		r.logs.Error(str.ErrorConcat(err, repositoryName, "GetTimeRadiusMapData"))
+		return nil, err
+	}
+
+	timeRadiusMap := make(entities.TimeRadiusMap, len(fields))
+	for id, entry := range fields {
+		if err = timeRadiusMap.DecodeEntry(id, []byte(entry)); err != nil {
+			r.logs.Error(str.ErrorConcat(err, repositoryName, "GetTimeRadiusMapData"))
+			return nil, err
+		}
 	}
+
+	return timeRadiusMap, nil
+}
+
+func (r *calculatorRepository) SetRestaurantGeoData(ctx context.Context, version string,
+	restaurants entities.Restaurants) error {
+	members := make([]redis.GeoMember, 0, len(restaurants))
+	for _, restaurant := range restaurants {
+		members = append(members, redis.GeoMember{
+			ID:   restaurant.ID,
+			Lat:  restaurant.Lat,
+			Long: restaurant.Long,
+		})
+	}
+
+	err := r.redis.GeoAddBulk(ctx, versionedKey(restaurantsGeoDataKey, version), members)
+	if err != nil {
+		r.logs.Error(str.ErrorConcat(err, repositoryName, "SetRestaurantGeoData"))
+		return err
+	}
+
 	return nil
 }
 
-func (r *calculatorRepository) GetRestaurantsInRadius(ctx context.Context, lat, long,
+// GetRestaurantsInRadius returns the restaurants indexed within radius km, the delivery radius and the rest of
+// their attributes are in the time radius map
+func (r *calculatorRepository) GetRestaurantsInRadius(ctx context.Context, version string, lat, long,
 	radius float64) ([]entities.RestaurantIDLatLng, error) {
 	var restaurants []entities.RestaurantIDLatLng
-	rawRestaurantsStrings, err := r.redis.GeoSearch(ctx, restaurantsGeoDataKey, lat, long, radius)
+	members, err := r.redis.GeoSearch(ctx, versionedKey(restaurantsGeoDataKey, version), lat, long, radius)
 	if err != nil {
 		if strings.Contains(err.Error(), invalidLatLongRedisErr) {
 			return restaurants, nil
@@ -98,52 +260,105 @@ func (r *calculatorRepository) GetRestaurantsInRadius(ctx context.Context, lat,
 		return restaurants, err
 	}
 
-	for _, restaurantString := range rawRestaurantsStrings {
-		restaurant, err := rawRestaurantStringToData(restaurantString)
-		if err != nil {
-			r.logs.Error(str.ErrorConcat(err, repositoryName, "GetRestaurantsInRadius"))
-			return restaurants, err
-		}
-		restaurants = append(restaurants, restaurant)
+	for _, member := range members {
+		restaurants = append(restaurants, entities.RestaurantIDLatLng{
+			ID:   member.ID,
+			Lat:  member.Lat,
+			Long: member.Long,
+		})
 	}
 
 	return restaurants, nil
 }
 
-func rawRestaurantStringToData(restaurantString string) (entities.RestaurantIDLatLng, error) {
-	parts := strings.Split(restaurantString, keySeparator)
-	var restaurant entities.RestaurantIDLatLng
+func (r *calculatorRepository) SetOverride(ctx context.Context, override entities.Override) error {
+	overrideBytes, _ := r.json.Marshal(override)
+	err := r.redis.HSet(ctx, overridesKey, override.ID, string(overrideBytes))
+	if err != nil {
+		r.logs.Error(str.ErrorConcat(err, repositoryName, "SetOverride"))
+		return err
+	}
+
+	return nil
+}
+
+// GetOverrides returns every override, ignoring the ones that cannot be decoded
+func (r *calculatorRepository) GetOverrides(ctx context.Context) ([]entities.Override, error) {
+	fields, err := r.redis.HGetAll(ctx, overridesKey)
+	if err != nil {
+		r.logs.Error(str.ErrorConcat(err, repositoryName, "GetOverrides"))
+		return nil, err
+	}
 
-	if len(parts) != keyParts {
-		err := errors.New("error: Input string does not have exactly 4 parts")
-		return restaurant, err
+	overrides := make([]entities.Override, 0, len(fields))
+	for id, field := range fields {
+		var override entities.Override
+		if err = r.json.Unmarshal([]byte(field), &override); err != nil {
+			r.logs.Warn(fmt.Sprintf("ignoring invalid override %s", id), fmt.Sprintf("%s.%s", repositoryName,
+				"GetOverrides"))
+			continue
+		}
+		overrides = append(overrides, override)
 	}
 
-	id := parts[0]
-	lat, err := strconv.ParseFloat(parts[1], bitSize)
+	return overrides, nil
+}
+
+// DeleteOverride tells if the override existed
+func (r *calculatorRepository) DeleteOverride(ctx context.Context, id string) (bool, error) {
+	removed, err := r.redis.HDel(ctx, overridesKey, id)
 	if err != nil {
-		err = errors.New(fmt.Sprintf("error converting %s to float64: %v", parts[1], err))
-		return restaurant, err
+		r.logs.Error(str.ErrorConcat(err, repositoryName, "DeleteOverride"))
+		return false, err
 	}
 
-	long, err := strconv.ParseFloat(parts[2], bitSize)
+	return removed > 0, nil
+}
+
+func (r *calculatorRepository) SetExclusion(ctx context.Context, exclusion entities.Exclusion) error {
+	exclusionBytes, _ := r.json.Marshal(exclusion)
+	err := r.redis.HSet(ctx, exclusionsKey, exclusion.ID, string(exclusionBytes))
 	if err != nil {
-		err = errors.New(fmt.Sprintf("error converting %s to float64: %v", parts[2], err))
-		return restaurant, err
+		r.logs.Error(str.ErrorConcat(err, repositoryName, "SetExclusion"))
+		return err
 	}
 
-	radius, err := strconv.ParseFloat(parts[3], bitSize)
+	return nil
+}
+
+// GetExclusions returns every exclusion, ignoring the ones that cannot be decoded
+func (r *calculatorRepository) GetExclusions(ctx context.Context) (entities.Exclusions, error) {
+	fields, err := r.redis.HGetAll(ctx, exclusionsKey)
 	if err != nil {
-		err = errors.New(fmt.Sprintf("Error converting %s to float64: %v\n", parts[3], err))
-		return restaurant, err
+		r.logs.Error(str.ErrorConcat(err, repositoryName, "GetExclusions"))
+		return nil, err
+	}
+
+	exclusions := make(entities.Exclusions, 0, len(fields))
+	for id, field := range fields {
+		var exclusion entities.Exclusion
+		if err = r.json.Unmarshal([]byte(field), &exclusion); err != nil {
+			r.logs.Warn(fmt.Sprintf("ignoring invalid exclusion %s", id), fmt.Sprintf("%s.%s", repositoryName,
+				"GetExclusions"))
+			continue
+		}
+		exclusions = append(exclusions, exclusion)
 	}
 
-	restaurant = entities.RestaurantIDLatLng{
-		ID:             id,
-		Lat:            lat,
-		Long:           long,
-		DeliveryRadius: radius,
+	return exclusions, nil
+}
+
+// DeleteExclusion tells if the exclusion existed
+func (r *calculatorRepository) DeleteExclusion(ctx context.Context, id string) (bool, error) {
+	removed, err := r.redis.HDel(ctx, exclusionsKey, id)
+	if err != nil {
+		r.logs.Error(str.ErrorConcat(err, repositoryName, "DeleteExclusion"))
+		return false, err
 	}
 
-	return restaurant, nil
+	return removed > 0, nil
+}
+
+func versionedKey(key, version string) string {
+	return fmt.Sprintf("%s:%s", key, version)
 }
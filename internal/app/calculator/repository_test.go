@@ -0,0 +1,123 @@
+package calculator_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/internal/app/calculator"
+	"github.com/sebastianreh/distance-calculator-api/internal/config"
+	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/sebastianreh/distance-calculator-api/pkg/geozone"
+	"github.com/sebastianreh/distance-calculator-api/pkg/logger"
+	"github.com/sebastianreh/distance-calculator-api/pkg/redis"
+	"github.com/sebastianreh/distance-calculator-api/test/standin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRepository(t *testing.T) calculator.CalculatorRepository {
+	logs := logger.NewLogger()
+	server, err := standin.NewRedisServer()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = server.Close() })
+
+	cfg := config.NewConfig()
+	cfg.Redis.Host = server.Addr()
+	cfg.Preprocess.BatchSize = 2
+	client, err := redis.NewRedis(logs, cfg)
+	require.NoError(t, err)
+
+	return calculator.NewCalculatorRepository(cfg, client, logs)
+}
+
+func Test_CalculatorRepository_RestaurantData(t *testing.T) {
+	ctx := context.Background()
+	repository := setupRepository(t)
+	restaurants := entities.Restaurants{
+		{ID: "palermo-soho-1", Lat: -34.5889, Long: -58.4305, Radius: 3, Rating: 4.2,
+			TimeZone: "America/Argentina/Buenos_Aires", Metadata: map[string]string{"cuisine": "parrilla"}},
+		{ID: "san-telmo-2", Lat: -34.6212, Long: -58.3731, Radius: 5, Rating: 3.8},
+		{ID: "recoleta-3", Lat: -34.5875, Long: -58.3974, Radius: 2.5},
+	}
+
+	require.NoError(t, repository.SetRestaurantGeoData(ctx, "1", restaurants))
+	// attributes are written batch by batch while preprocessing
+	require.NoError(t, repository.SetTimeRadiusMapData(ctx, "1", restaurants[:2].CreateTimeRadiusMap()))
+	require.NoError(t, repository.SetTimeRadiusMapData(ctx, "1", restaurants[2:].CreateTimeRadiusMap()))
+
+	t.Run("ids with dashes and negative coordinates", func(t *testing.T) {
+		found, err := repository.GetRestaurantsInRadius(ctx, "1", -34.5889, -58.4305, 1)
+
+		assert.NoError(t, err)
+		assert.Len(t, found, 1)
+		assert.Equal(t, "palermo-soho-1", found[0].ID)
+		assert.InDelta(t, -34.5889, found[0].Lat, 1e-6)
+		assert.InDelta(t, -58.4305, found[0].Long, 1e-6)
+	})
+
+	t.Run("attributes of every restaurant", func(t *testing.T) {
+		timeRadiusMap, err := repository.GetTimeRadiusMapData(ctx, "1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, restaurants.CreateTimeRadiusMap(), timeRadiusMap)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		timeRadiusMap, err := repository.GetTimeRadiusMapData(ctx, "2")
+
+		assert.NoError(t, err)
+		assert.Empty(t, timeRadiusMap)
+	})
+}
+
+func Test_CalculatorRepository_Overrides(t *testing.T) {
+	ctx := context.Background()
+	repository := setupRepository(t)
+	closure := entities.Override{ID: "1", RestaurantID: "palermo-soho-1", From: "2023-12-25", To: "2023-12-25",
+		Hours: "closed", Reason: "christmas", CreatedAt: time.Date(2023, 12, 1, 10, 0, 0, 0, time.UTC)}
+
+	require.NoError(t, repository.SetOverride(ctx, closure))
+
+	overrides, err := repository.GetOverrides(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []entities.Override{closure}, overrides)
+
+	deleted, err := repository.DeleteOverride(ctx, closure.ID)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = repository.DeleteOverride(ctx, closure.ID)
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+
+	overrides, err = repository.GetOverrides(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, overrides)
+}
+
+func Test_CalculatorRepository_Exclusions(t *testing.T) {
+	ctx := context.Background()
+	repository := setupRepository(t)
+	var zone geozone.Zone
+	require.NoError(t, json.Unmarshal([]byte(`{"type": "Polygon", "coordinates": [
+		[[-0.49, 51.46], [-0.42, 51.46], [-0.42, 51.48], [-0.49, 51.48], [-0.49, 51.46]]]}`), &zone))
+	to := time.Date(2023, 12, 2, 8, 0, 0, 0, time.UTC)
+	airport := entities.Exclusion{ID: "1", Name: "airport", Zone: zone, To: &to, Reason: "runway works",
+		CreatedAt: time.Date(2023, 12, 1, 10, 0, 0, 0, time.UTC)}
+
+	require.NoError(t, repository.SetExclusion(ctx, airport))
+
+	exclusions, err := repository.GetExclusions(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, entities.Exclusions{airport}, exclusions)
+
+	deleted, err := repository.DeleteExclusion(ctx, airport.ID)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = repository.DeleteExclusion(ctx, airport.ID)
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+}
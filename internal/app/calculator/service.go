@@ -2,13 +2,18 @@ package calculator
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"sync"
+	"time"
 
 	"github.com/sebastianreh/distance-calculator-api/internal/config"
 	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/sebastianreh/distance-calculator-api/internal/entities/exceptions"
 	customCsv "github.com/sebastianreh/distance-calculator-api/pkg/csv"
 	"github.com/sebastianreh/distance-calculator-api/pkg/logger"
-	"github.com/sebastianreh/distance-calculator-api/pkg/rest"
+	"github.com/sebastianreh/distance-calculator-api/pkg/source"
 	str "github.com/sebastianreh/distance-calculator-api/pkg/strings"
 )
 
@@ -18,101 +23,345 @@ const (
 
 type CalculatorService interface {
 	CalculateDeliveryRange(ctx context.Context, request entities.CalculationRequest) (entities.CalculationResponse, error)
-	PreprocessRestaurants(ctx context.Context) error
+	CalculateBatchDeliveryRange(ctx context.Context, locations []entities.BatchLocation) (entities.BatchCalculationResponse,
+		error)
+	CheckDeliverability(ctx context.Context, request entities.DeliverabilityRequest) (entities.DeliverabilityResponse,
+		error)
+	PreprocessRestaurants(ctx context.Context, dryRun bool) (entities.PreprocessReport, error)
+	WatchDatasets(ctx context.Context)
+	CreateOverride(ctx context.Context, override entities.Override) (entities.Override, error)
+	ListOverrides(ctx context.Context, restaurantID string) ([]entities.Override, error)
+	DeleteOverride(ctx context.Context, id string) error
+	CreateExclusion(ctx context.Context, exclusion entities.Exclusion) (entities.Exclusion, error)
+	ListExclusions(ctx context.Context) (entities.Exclusions, error)
+	DeleteExclusion(ctx context.Context, id string) error
 }
 
 type calculatorService struct {
-	config     config.Config
-	repository CalculatorRepository
-	restClient rest.S3Client
-	logs       logger.Logger
+	config        config.Config
+	repository    CalculatorRepository
+	source        source.RestaurantSource
+	zonesSource   source.RestaurantSource
+	columnAliases entities.ColumnAliases
+	cache         datasetCache
+	overrides     refreshCache[entities.Overrides]
+	exclusions    refreshCache[entities.Exclusions]
+	logs          logger.Logger
 }
 
-func NewCalculatorService(cfg config.Config, repository CalculatorRepository, restClient rest.S3Client,
-	logs logger.Logger) CalculatorService {
+// NewCalculatorService builds the service, zonesSource is nil when no delivery zones file is configured
+func NewCalculatorService(cfg config.Config, repository CalculatorRepository, restaurantSource source.RestaurantSource,
+	zonesSource source.RestaurantSource, logs logger.Logger) CalculatorService {
 	return &calculatorService{
-		config:     cfg,
-		repository: repository,
-		restClient: restClient,
-		logs:       logs,
+		config:        cfg,
+		repository:    repository,
+		source:        restaurantSource,
+		zonesSource:   zonesSource,
+		columnAliases: entities.ParseColumnAliases(cfg.Preprocess.ColumnAliases),
+		logs:          logs,
 	}
 }
 
-func (r *calculatorService) PreprocessRestaurants(ctx context.Context) error {
-	restaurantRecordsBytes, err := r.restClient.GetRestaurantsCSV()
+// PreprocessRestaurants streams the restaurants feed into a new dataset version, writing the locations and
+// attributes of restaurants to the repository in batches so memory stays bounded by the batch size rather than
+// the feed size. Only the ids of accepted restaurants are kept for the whole run, to reject duplicates. A dry run
+// validates the feed and reports rejected rows without writing anything. Restaurants with a zone in the delivery
+// zones file deliver within it instead of their radius.
+func (r *calculatorService) PreprocessRestaurants(ctx context.Context, dryRun bool) (entities.PreprocessReport, error) {
+	report := entities.NewPreprocessReport(dryRun)
+	zones, err := r.loadDeliveryZones(ctx)
 	if err != nil {
-		return err
+		return report, err
 	}
 
-	restaurantRecords, err := customCsv.CsvBytesToRecords(restaurantRecordsBytes)
+	restaurantsFeed, err := r.source.Open(ctx)
 	if err != nil {
-		r.logs.Error(str.ErrorConcat(err, serviceName, "PreprocessRestaurants"))
-		return err
+		return report, err
 	}
+	defer restaurantsFeed.Close()
 
-	restaurants, err := entities.MapRecordsToRestaurants(restaurantRecords, r.logs)
+	decoder, err := entities.NewRestaurantDecoder(customCsv.NewRecordReader(restaurantsFeed), r.columnAliases,
+		r.config.Preprocess.DefaultTimeZone)
 	if err != nil {
 		r.logs.Error(str.ErrorConcat(err, serviceName, "PreprocessRestaurants"))
-		return err
+		return report, err
+	}
+
+	dataset := entities.NewDataset(time.Now())
+	if !dryRun {
+		err = r.repository.RegisterDataset(ctx, dataset)
+		if err != nil {
+			return report, err
+		}
+		report.DatasetVersion = dataset.Version
+	}
+
+	batchSize := r.config.Preprocess.BatchSize
+	maxRejections := r.config.Preprocess.MaxReportedRejections
+	batch := make(entities.Restaurants, 0, batchSize)
+	accepted := make(map[string]struct{})
+	for {
+		restaurant, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+
+		var rowErr *entities.RowError
+		if err != nil && !errors.As(err, &rowErr) {
+			r.logs.Error(str.ErrorConcat(err, serviceName, "PreprocessRestaurants"))
+			return report, err
+		}
+
+		report.RowsRead++
+		r.logProgress(report)
+		if rowErr == nil {
+			if _, duplicated := accepted[restaurant.ID]; duplicated {
+				rowErr = &entities.RowError{Line: decoder.Line(), Column: entities.ColumnID,
+					Value: restaurant.ID, Reason: "duplicated id"}
+			}
+		}
+		if rowErr != nil {
+			report.Reject(rowErr, maxRejections)
+			continue
+		}
+
+		report.RowsAccepted++
+		if zone, ok := zones[restaurant.ID]; ok {
+			restaurant.Zone = zone
+			report.ZonesApplied++
+		}
+		accepted[restaurant.ID] = struct{}{}
+		dataset.TrackRadius(restaurant.MaxRadius())
+		if dryRun {
+			continue
+		}
+
+		batch = append(batch, restaurant)
+		if len(batch) == batchSize {
+			err = r.writeBatch(ctx, dataset.Version, batch)
+			if err != nil {
+				return report, err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	r.logs.Info(fmt.Sprintf("Preprocessed %d rows, %d accepted, %d rejected", report.RowsRead,
+		report.RowsAccepted, report.RowsRejected), fmt.Sprintf("%s.%s", serviceName, "PreprocessRestaurants"))
+	if unmatched := len(zones) - report.ZonesApplied; unmatched > 0 {
+		r.logs.Warn(fmt.Sprintf("%d delivery zones do not match an accepted restaurant", unmatched),
+			fmt.Sprintf("%s.%s", serviceName, "PreprocessRestaurants"))
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	if report.RowsAccepted == 0 {
+		r.logs.Error("restaurants CSV has no valid rows, dataset not published",
+			fmt.Sprintf("%s.%s", serviceName, "PreprocessRestaurants"))
+		return report, nil
 	}
 
-	err = r.repository.SetRestaurantGeoData(ctx, restaurants)
+	err = r.writeBatch(ctx, dataset.Version, batch)
 	if err != nil {
-		return err
+		return report, err
 	}
 
-	timeRadiusMap := restaurants.CreateTimeRadiusMap()
-	err = r.repository.SetTimeRadiusMapData(ctx, timeRadiusMap)
+	err = r.repository.PublishDataset(ctx, dataset)
+	if err != nil {
+		return report, err
+	}
+	report.Published = true
+	r.cache.invalidate()
+
+	return report, nil
+}
+
+// writeBatch stores the locations and the attributes of a batch of restaurants of the dataset version
+func (r *calculatorService) writeBatch(ctx context.Context, version string, batch entities.Restaurants) error {
+	err := r.repository.SetRestaurantGeoData(ctx, version, batch)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return r.repository.SetTimeRadiusMapData(ctx, version, batch.CreateTimeRadiusMap())
+}
+
+// loadDeliveryZones reads the delivery zones file, when configured, failing the whole run if it is invalid so
+// restaurants never fall back to their radius by mistake
+func (r *calculatorService) loadDeliveryZones(ctx context.Context) (entities.DeliveryZones, error) {
+	if r.zonesSource == nil {
+		return nil, nil
+	}
+
+	zonesFile, err := r.zonesSource.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer zonesFile.Close()
+
+	zones, err := entities.ReadDeliveryZones(zonesFile)
+	if err != nil {
+		r.logs.Error(str.ErrorConcat(err, serviceName, "loadDeliveryZones"))
+		return nil, err
+	}
+
+	return zones, nil
+}
+
+func (r *calculatorService) logProgress(report entities.PreprocessReport) {
+	progressInterval := r.config.Preprocess.ProgressInterval
+	if progressInterval > 0 && report.RowsRead%progressInterval == 0 {
+		r.logs.Info(fmt.Sprintf("Preprocessed %d rows, %d accepted", report.RowsRead, report.RowsAccepted),
+			fmt.Sprintf("%s.%s", serviceName, "PreprocessRestaurants"))
+	}
 }
 
+// CalculateDeliveryRange returns the restaurants able to deliver to the customer, none when the customer is inside
+// an exclusion zone, checked before searching for candidates
 func (r *calculatorService) CalculateDeliveryRange(ctx context.Context,
 	request entities.CalculationRequest) (entities.CalculationResponse, error) {
 	var response entities.CalculationResponse
-	var timeRadiusMap entities.TimeRadiusMap
-	var restaurantInUserRadius []entities.RestaurantIDLatLng
-	const parallelProcesses = 3
 
-	var wg sync.WaitGroup
+	request, err := request.ResumeCursor()
+	if err != nil {
+		return response, exceptions.NewBadRequestException(err.Error())
+	}
 
-	errChan := make(chan error, parallelProcesses)
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		timeRadiusMapData, err := r.repository.GetTimeRadiusMapData(ctx)
-		if err != nil {
-			errChan <- err
-			return
-		}
-		timeRadiusMap = timeRadiusMapData
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		restaurantInUserRadiusData, err := r.repository.GetRestaurantsInRadius(ctx,
-			request.Lat, request.Long, r.config.MaxDeliveryRadius)
-		if err != nil {
-			errChan <- err
-			return
-		}
-		restaurantInUserRadius = restaurantInUserRadiusData
-	}()
+	excluded, err := r.excluded(ctx, request.Lat, request.Long, request.Now)
+	if err != nil {
+		return response, err
+	}
+	if excluded {
+		response.RestaurantIDs = make([]string, 0)
+		response.Reason = entities.ReasonExclusionZone
+		return response, nil
+	}
 
-	wg.Wait()
-	close(errChan)
+	dataset, timeRadiusMap, overrides, err := r.currentAvailability(ctx)
+	if err != nil {
+		return response, err
+	}
+	if dataset.IsEmpty() {
+		response.RestaurantIDs = make([]string, 0)
+		return response, nil
+	}
+
+	restaurantInUserRadius, err := r.repository.GetRestaurantsInRadius(ctx, dataset.Version, request.Lat, request.Long,
+		dataset.SearchRadius(r.config.MaxDeliveryRadius))
+	if err != nil {
+		return response, err
+	}
+
+	restaurants, nextCursor, err := request.Paginate(request.FindRestaurantsInRadius(timeRadiusMap, overrides,
+		restaurantInUserRadius), dataset.Version)
+	if err != nil {
+		return response, exceptions.NewBadRequestException(err.Error())
+	}
+
+	response = request.NewCalculationResponse(restaurants)
+	response.NextCursor = nextCursor
+	if request.Explains() {
+		response.Explanation = request.Explain(timeRadiusMap, overrides, restaurantInUserRadius,
+			dataset.SearchRadius(r.config.MaxDeliveryRadius))
+	}
+
+	return response, nil
+}
+
+// CalculateBatchDeliveryRange calculates the available restaurants of many locations loading the time radius map
+// once, with the geo searches spread over a bounded pool of workers. A failed search is reported in the result of
+// its location instead of failing the whole batch.
+func (r *calculatorService) CalculateBatchDeliveryRange(ctx context.Context,
+	locations []entities.BatchLocation) (entities.BatchCalculationResponse, error) {
+	response := entities.NewBatchCalculationResponse(len(locations))
 
-	for e := range errChan {
-		if e != nil {
-			return response, e
+	exclusions, err := r.currentExclusions(ctx)
+	if err != nil {
+		return response, err
+	}
+
+	dataset, timeRadiusMap, overrides, err := r.currentAvailability(ctx)
+	if err != nil {
+		return response, err
+	}
+	if dataset.IsEmpty() {
+		for _, location := range locations {
+			response.Results[location.ID] = entities.BatchLocationResult{RestaurantIDs: make([]string, 0)}
 		}
+		return response, nil
 	}
 
-	response.RestaurantIDs = request.FindRestaurantsInRadius(timeRadiusMap, restaurantInUserRadius)
+	workers := r.config.Batch.Workers
+	if workers <= 0 || workers > len(locations) {
+		workers = len(locations)
+	}
+
+	results := make([]entities.BatchLocationResult, len(locations))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				results[index] = r.calculateLocation(ctx, dataset, timeRadiusMap, overrides, exclusions,
+					locations[index])
+			}
+		}()
+	}
+
+	for i := range locations {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i, location := range locations {
+		response.Results[location.ID] = results[i]
+	}
 
 	return response, nil
 }
+
+func (r *calculatorService) calculateLocation(ctx context.Context, dataset entities.Dataset,
+	timeRadiusMap entities.TimeRadiusMap, overrides entities.Overrides, exclusions entities.Exclusions,
+	location entities.BatchLocation) entities.BatchLocationResult {
+	if _, excluded := exclusions.Excluding(location.Lat, location.Long, location.Now); excluded {
+		return entities.BatchLocationResult{RestaurantIDs: make([]string, 0), Reason: entities.ReasonExclusionZone}
+	}
+
+	restaurantInUserRadius, err := r.repository.GetRestaurantsInRadius(ctx, dataset.Version, location.Lat,
+		location.Long, dataset.SearchRadius(r.config.MaxDeliveryRadius))
+	if err != nil {
+		r.logs.Error(str.ErrorConcat(err, serviceName, "CalculateBatchDeliveryRange"))
+		return entities.BatchLocationResult{RestaurantIDs: make([]string, 0), Error: err.Error()}
+	}
+
+	request := location.CalculationRequest()
+	response := request.NewCalculationResponse(request.FindRestaurantsInRadius(timeRadiusMap, overrides,
+		restaurantInUserRadius))
+
+	return entities.BatchLocationResult{RestaurantIDs: response.RestaurantIDs}
+}
+
+// CheckDeliverability explains if a single restaurant of the current dataset can deliver to the customer
+func (r *calculatorService) CheckDeliverability(ctx context.Context,
+	request entities.DeliverabilityRequest) (entities.DeliverabilityResponse, error) {
+	excluded, err := r.excluded(ctx, request.Lat, request.Long, request.Now)
+	if err != nil {
+		return entities.DeliverabilityResponse{}, err
+	}
+	if excluded {
+		return entities.DeliverabilityResponse{RestaurantID: request.RestaurantID,
+			Reason: entities.ReasonExclusionZone}, nil
+	}
+
+	dataset, timeRadiusMap, overrides, err := r.currentAvailability(ctx)
+	if err != nil {
+		return entities.DeliverabilityResponse{}, err
+	}
+
+	return request.CheckDeliverability(timeRadiusMap, overrides, dataset.SearchRadius(r.config.MaxDeliveryRadius)), nil
+}
@@ -0,0 +1,713 @@
+package calculator_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/internal/app/calculator"
+	"github.com/sebastianreh/distance-calculator-api/internal/config"
+	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/sebastianreh/distance-calculator-api/internal/entities/exceptions"
+	"github.com/sebastianreh/distance-calculator-api/pkg/logger"
+	"github.com/sebastianreh/distance-calculator-api/pkg/schedule"
+	"github.com/sebastianreh/distance-calculator-api/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const restaurantsCSV = `id,latitude,longitude,availability_radius,open_hour,close_hour,rating
+1,51.5,-0.1,5,10:00,22:00,4.5
+2,51.6,-0.2,3,11:00,23:00,3.9
+3,bad,-0.2,3,11:00,23:00,3.9
+4,51.7,-0.3,4,09:00,18:00,4.1
+`
+
+func Test_CalculatorService_PreprocessRestaurants(t *testing.T) {
+	logs := logger.NewLogger()
+	cfg := config.NewConfig()
+	cfg.Preprocess.BatchSize = 2
+	ctx := context.Background()
+
+	t.Run("successful preprocessing in batches", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		sourceMock := mocks.NewRestaurantSourceMock()
+		sourceMock.On("Open", ctx).Return(restaurantsCSV, nil)
+
+		repositoryMock.On("RegisterDataset", ctx, mock.AnythingOfType("entities.Dataset")).Return(nil)
+		repositoryMock.On("SetRestaurantGeoData", ctx, mock.AnythingOfType("string"),
+			mock.MatchedBy(func(batch entities.Restaurants) bool {
+				return len(batch) == 2 && batch[0].ID == "1" && batch[1].ID == "2"
+			})).Return(nil).Once()
+		repositoryMock.On("SetRestaurantGeoData", ctx, mock.AnythingOfType("string"),
+			mock.MatchedBy(func(batch entities.Restaurants) bool {
+				return len(batch) == 1 && batch[0].ID == "4"
+			})).Return(nil).Once()
+		repositoryMock.On("SetTimeRadiusMapData", ctx, mock.AnythingOfType("string"),
+			mock.MatchedBy(func(timeRadiusMap entities.TimeRadiusMap) bool {
+				_, first := timeRadiusMap["1"]
+				_, second := timeRadiusMap["2"]
+				return len(timeRadiusMap) == 2 && first && second
+			})).Return(nil).Once()
+		repositoryMock.On("SetTimeRadiusMapData", ctx, mock.AnythingOfType("string"),
+			mock.MatchedBy(func(timeRadiusMap entities.TimeRadiusMap) bool {
+				_, fourth := timeRadiusMap["4"]
+				return len(timeRadiusMap) == 1 && fourth
+			})).Return(nil).Once()
+		repositoryMock.On("PublishDataset", ctx, mock.MatchedBy(func(dataset entities.Dataset) bool {
+			return dataset.MaxRadius == 5
+		})).Return(nil)
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, sourceMock, nil, logs)
+		report, err := service.PreprocessRestaurants(ctx, false)
+
+		assert.NoError(t, err)
+		assert.True(t, report.Published)
+		assert.Equal(t, 4, report.RowsRead)
+		assert.Equal(t, 3, report.RowsAccepted)
+		assert.Equal(t, []entities.RowRejection{{Line: 4, Column: entities.ColumnLat, Value: "bad",
+			Reason: "unparseable latitude"}}, report.Rejections)
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("dry run writes nothing", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		sourceMock := mocks.NewRestaurantSourceMock()
+		sourceMock.On("Open", ctx).Return(restaurantsCSV+"1,51.5,-0.1,5,25:00,22:00,4.5\n", nil)
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, sourceMock, nil, logs)
+		report, err := service.PreprocessRestaurants(ctx, true)
+
+		assert.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.False(t, report.Published)
+		assert.Equal(t, 3, report.RowsAccepted)
+		assert.Equal(t, 2, report.RowsRejected)
+		assert.Equal(t, entities.ColumnOpen, report.Rejections[1].Column)
+		repositoryMock.AssertNotCalled(t, "RegisterDataset", mock.Anything, mock.Anything)
+		repositoryMock.AssertNotCalled(t, "SetRestaurantGeoData", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("duplicated ids are rejected", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		sourceMock := mocks.NewRestaurantSourceMock()
+		sourceMock.On("Open", ctx).Return(restaurantsCSV+"1,51.5,-0.1,5,10:00,22:00,4.5\n", nil)
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, sourceMock, nil, logs)
+		report, err := service.PreprocessRestaurants(ctx, true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, entities.RowRejection{Line: 6, Column: entities.ColumnID, Value: "1",
+			Reason: "duplicated id"}, report.Rejections[1])
+	})
+
+	t.Run("columns resolved by header with aliases and extra columns", func(t *testing.T) {
+		aliasesCfg := cfg
+		aliasesCfg.Preprocess.ColumnAliases = map[string]string{"latitude": "lat|lat_deg", "longitude": "lng"}
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		sourceMock := mocks.NewRestaurantSourceMock()
+		sourceMock.On("Open", ctx).Return("name,rating,LNG,Lat,id,close_hour,open_hour,availability_radius,cuisine,Monday,timezone\n"+
+			"Pizzeria,4.5,-0.1,51.5,1,22:00,10:00,5,italian,closed,Europe/London\n", nil)
+		opening, closing := schedule.NewClock(10, 0), schedule.NewClock(22, 0)
+		weeklySchedule := schedule.NewWeek(schedule.Day{Intervals: []schedule.Interval{{Open: opening, Close: closing}}})
+		weeklySchedule[time.Monday] = schedule.Closed
+
+		repositoryMock.On("RegisterDataset", ctx, mock.AnythingOfType("entities.Dataset")).Return(nil)
+		repositoryMock.On("SetRestaurantGeoData", ctx, mock.AnythingOfType("string"), entities.Restaurants{{
+			ID: "1", Lat: 51.5, Long: -0.1, Radius: 5, Open: opening, Close: closing, Rating: 4.5, Schedule: weeklySchedule,
+			TimeZone: "Europe/London", Metadata: map[string]string{"name": "Pizzeria", "cuisine": "italian"},
+		}}).Return(nil)
+		repositoryMock.On("SetTimeRadiusMapData", ctx, mock.AnythingOfType("string"),
+			mock.AnythingOfType("entities.TimeRadiusMap")).Return(nil)
+		repositoryMock.On("PublishDataset", ctx, mock.AnythingOfType("entities.Dataset")).Return(nil)
+
+		service := calculator.NewCalculatorService(aliasesCfg, repositoryMock, sourceMock, nil, logs)
+		report, err := service.PreprocessRestaurants(ctx, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.RowsAccepted)
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("radius rules widen the search radius", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		sourceMock := mocks.NewRestaurantSourceMock()
+		sourceMock.On("Open", ctx).Return("id,latitude,longitude,availability_radius,open_hour,close_hour,rating,"+
+			"radius_rules\n1,51.5,-0.1,5,10:00,22:00,4.5,17:00-20:00=3;sat 20:00-23:00=8\n"+
+			"2,51.5,-0.1,5,10:00,22:00,4.5,evenings\n", nil)
+
+		repositoryMock.On("RegisterDataset", ctx, mock.AnythingOfType("entities.Dataset")).Return(nil)
+		repositoryMock.On("SetRestaurantGeoData", ctx, mock.AnythingOfType("string"),
+			mock.MatchedBy(func(batch entities.Restaurants) bool {
+				return len(batch) == 1 && len(batch[0].RadiusRules) == 2
+			})).Return(nil)
+		repositoryMock.On("SetTimeRadiusMapData", ctx, mock.AnythingOfType("string"),
+			mock.AnythingOfType("entities.TimeRadiusMap")).Return(nil)
+		repositoryMock.On("PublishDataset", ctx, mock.MatchedBy(func(dataset entities.Dataset) bool {
+			return dataset.MaxRadius == 8
+		})).Return(nil)
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, sourceMock, nil, logs)
+		report, err := service.PreprocessRestaurants(ctx, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.RowsAccepted)
+		assert.Equal(t, entities.ColumnRadiusRules, report.Rejections[0].Column)
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("delivery zones replace the radius", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		sourceMock := mocks.NewRestaurantSourceMock()
+		sourceMock.On("Open", ctx).Return(restaurantsCSV, nil)
+		zonesSourceMock := mocks.NewRestaurantSourceMock()
+		zonesSourceMock.On("Open", ctx).Return(`{"type": "FeatureCollection", "features": [
+			{"type": "Feature", "properties": {"restaurant_id": "1"}, "geometry": {"type": "Polygon",
+				"coordinates": [[[-0.1, 51.5], [0, 51.5], [0, 51.45], [-0.1, 51.5]]]}},
+			{"type": "Feature", "properties": {"restaurant_id": "9"}, "geometry": {"type": "Polygon",
+				"coordinates": [[[-0.1, 51.5], [0, 51.5], [0, 51.45], [-0.1, 51.5]]]}}]}`, nil)
+
+		repositoryMock.On("RegisterDataset", ctx, mock.AnythingOfType("entities.Dataset")).Return(nil)
+		repositoryMock.On("SetRestaurantGeoData", ctx, mock.AnythingOfType("string"),
+			mock.AnythingOfType("entities.Restaurants")).Return(nil)
+		repositoryMock.On("SetTimeRadiusMapData", ctx, mock.AnythingOfType("string"),
+			mock.MatchedBy(func(timeRadiusMap entities.TimeRadiusMap) bool {
+				return len(timeRadiusMap) == 2 && timeRadiusMap["1"].Zone != nil && timeRadiusMap["2"].Zone == nil
+			})).Return(nil).Once()
+		repositoryMock.On("SetTimeRadiusMapData", ctx, mock.AnythingOfType("string"),
+			mock.MatchedBy(func(timeRadiusMap entities.TimeRadiusMap) bool {
+				return len(timeRadiusMap) == 1 && timeRadiusMap["4"].Zone == nil
+			})).Return(nil).Once()
+		repositoryMock.On("PublishDataset", ctx, mock.MatchedBy(func(dataset entities.Dataset) bool {
+			// the zone reaches about 8.9 km away from restaurant 1
+			return dataset.MaxRadius > 8 && dataset.MaxRadius < 9
+		})).Return(nil)
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, sourceMock, zonesSourceMock, logs)
+		report, err := service.PreprocessRestaurants(ctx, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.ZonesApplied)
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("invalid delivery zones", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		sourceMock := mocks.NewRestaurantSourceMock()
+		zonesSourceMock := mocks.NewRestaurantSourceMock()
+		zonesSourceMock.On("Open", ctx).Return(`{"type": "FeatureCollection", "features": [{"type": "Feature",
+			"properties": {"restaurant_id": "1"}, "geometry": {"type": "Point", "coordinates": [0, 51.5]}}]}`, nil)
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, sourceMock, zonesSourceMock, logs)
+		_, err := service.PreprocessRestaurants(ctx, false)
+
+		assert.Error(t, err)
+		sourceMock.AssertNotCalled(t, "Open", mock.Anything)
+		repositoryMock.AssertNotCalled(t, "RegisterDataset", mock.Anything, mock.Anything)
+	})
+
+	t.Run("missing required columns", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		sourceMock := mocks.NewRestaurantSourceMock()
+		sourceMock.On("Open", ctx).Return("id,lat,rating,open_hour,close_hour\n1,2,3,10:00,11:00\n", nil)
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, sourceMock, nil, logs)
+		_, err := service.PreprocessRestaurants(ctx, false)
+
+		assert.EqualError(t, err, "CSV is missing required columns: latitude, longitude, availability_radius")
+		repositoryMock.AssertNotCalled(t, "RegisterDataset", mock.Anything, mock.Anything)
+	})
+
+	t.Run("source error", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		sourceMock := mocks.NewRestaurantSourceMock()
+		sourceMock.On("Open", ctx).Return(nil, errors.New("source error"))
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, sourceMock, nil, logs)
+		_, err := service.PreprocessRestaurants(ctx, false)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("dataset is not published without valid rows", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		sourceMock := mocks.NewRestaurantSourceMock()
+		sourceMock.On("Open", ctx).Return("id,latitude,longitude,availability_radius,open_hour,close_hour,rating\n"+
+			"1,bad,-0.1,5,10:00,22:00,4.5\n", nil)
+		repositoryMock.On("RegisterDataset", ctx, mock.AnythingOfType("entities.Dataset")).Return(nil)
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, sourceMock, nil, logs)
+		report, err := service.PreprocessRestaurants(ctx, false)
+
+		assert.NoError(t, err)
+		assert.False(t, report.Published)
+		assert.Equal(t, 1, report.RowsRejected)
+		repositoryMock.AssertNotCalled(t, "PublishDataset", mock.Anything, mock.Anything)
+	})
+}
+
+func Test_CalculatorService_CalculateBatchDeliveryRange(t *testing.T) {
+	logs := logger.NewLogger()
+	cfg := config.NewConfig()
+	cfg.Batch.Workers = 2
+	ctx := context.Background()
+	dataset := entities.Dataset{Version: "1"}
+	allDay := schedule.NewWeek(schedule.Day{Intervals: []schedule.Interval{schedule.AllDay}})
+	timeRadiusMap := entities.Restaurants{
+		{ID: "1", Lat: 51.5, Long: -0.1, Radius: 5, Schedule: allDay},
+		{ID: "2", Lat: 51.6, Long: -0.2, Radius: 3, Schedule: allDay},
+	}.CreateTimeRadiusMap()
+	locations := []entities.BatchLocation{
+		{ID: "a", Lat: 51.5, Long: -0.1, Now: time.Now()},
+		{ID: "b", Lat: 51.6, Long: -0.2, Now: time.Now()},
+		{ID: "c", Lat: 95, Long: -0.2, Now: time.Now()},
+	}
+
+	t.Run("time radius map loaded once for every location", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("GetCurrentDataset", ctx).Return(dataset, nil)
+		repositoryMock.On("GetTimeRadiusMapData", ctx, dataset.Version).Return(timeRadiusMap, nil).Once()
+		repositoryMock.On("GetOverrides", ctx).Return([]entities.Override{}, nil)
+		repositoryMock.On("GetExclusions", ctx).Return(entities.Exclusions{}, nil)
+		repositoryMock.On("GetRestaurantsInRadius", ctx, dataset.Version, 51.5, -0.1, cfg.MaxDeliveryRadius).
+			Return([]entities.RestaurantIDLatLng{{ID: "1", Lat: 51.5, Long: -0.1}, {ID: "2", Lat: 51.6, Long: -0.2}}, nil)
+		repositoryMock.On("GetRestaurantsInRadius", ctx, dataset.Version, 51.6, -0.2, cfg.MaxDeliveryRadius).
+			Return([]entities.RestaurantIDLatLng{{ID: "2", Lat: 51.6, Long: -0.2}}, nil)
+		repositoryMock.On("GetRestaurantsInRadius", ctx, dataset.Version, 95.0, -0.2, cfg.MaxDeliveryRadius).
+			Return([]entities.RestaurantIDLatLng(nil), errors.New("invalid latitude"))
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, mocks.NewRestaurantSourceMock(), nil, logs)
+		response, err := service.CalculateBatchDeliveryRange(ctx, locations)
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]entities.BatchLocationResult{
+			"a": {RestaurantIDs: []string{"1"}},
+			"b": {RestaurantIDs: []string{"2"}},
+			"c": {RestaurantIDs: []string{}, Error: "invalid latitude"},
+		}, response.Results)
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("searches within the dataset max radius", func(t *testing.T) {
+		wideDataset := entities.Dataset{Version: "2", MaxRadius: 20}
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("GetCurrentDataset", ctx).Return(wideDataset, nil)
+		repositoryMock.On("GetTimeRadiusMapData", ctx, wideDataset.Version).Return(timeRadiusMap, nil)
+		repositoryMock.On("GetOverrides", ctx).Return([]entities.Override{}, nil)
+		repositoryMock.On("GetExclusions", ctx).Return(entities.Exclusions{}, nil)
+		repositoryMock.On("GetRestaurantsInRadius", ctx, wideDataset.Version, 51.5, -0.1, wideDataset.SearchRadius(0)).
+			Return([]entities.RestaurantIDLatLng{{ID: "1", Lat: 51.5, Long: -0.1}}, nil)
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, mocks.NewRestaurantSourceMock(), nil, logs)
+		response, err := service.CalculateBatchDeliveryRange(ctx, locations[:1])
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1"}, response.Results["a"].RestaurantIDs)
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("no published dataset", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("GetCurrentDataset", ctx).Return(entities.Dataset{}, nil)
+		repositoryMock.On("GetExclusions", ctx).Return(entities.Exclusions{}, nil)
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, mocks.NewRestaurantSourceMock(), nil, logs)
+		response, err := service.CalculateBatchDeliveryRange(ctx, locations)
+
+		assert.NoError(t, err)
+		assert.Len(t, response.Results, 3)
+		assert.Empty(t, response.Results["a"].RestaurantIDs)
+	})
+}
+
+func Test_CalculatorService_DatasetCache(t *testing.T) {
+	logs := logger.NewLogger()
+	ctx := context.Background()
+	allDay := schedule.NewWeek(schedule.Day{Intervals: []schedule.Interval{schedule.AllDay}})
+	firstMap := entities.Restaurants{{ID: "1", Lat: 51.5, Long: -0.1, Radius: 5, Schedule: allDay}}.CreateTimeRadiusMap()
+	secondMap := entities.Restaurants{{ID: "2", Lat: 51.5, Long: -0.1, Radius: 5, Schedule: allDay}}.CreateTimeRadiusMap()
+	first, second := entities.Dataset{Version: "1"}, entities.Dataset{Version: "2"}
+	request := entities.DeliverabilityRequest{RestaurantID: "2", Lat: 51.5, Long: -0.1, Now: time.Now()}
+
+	t.Run("served from memory within the refresh interval", func(t *testing.T) {
+		cfg := config.NewConfig()
+		cfg.DatasetRefreshInterval = time.Hour
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("GetCurrentDataset", ctx).Return(first, nil).Once()
+		repositoryMock.On("GetTimeRadiusMapData", ctx, first.Version).Return(firstMap, nil).Once()
+		repositoryMock.On("GetOverrides", ctx).Return([]entities.Override{}, nil).Once()
+		repositoryMock.On("GetExclusions", ctx).Return(entities.Exclusions{}, nil)
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, mocks.NewRestaurantSourceMock(), nil, logs)
+		for i := 0; i < 3; i++ {
+			response, err := service.CheckDeliverability(ctx, request)
+			assert.NoError(t, err)
+			assert.Equal(t, entities.ReasonUnknownRestaurant, response.Reason)
+		}
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("map reloaded only when the version changes", func(t *testing.T) {
+		cfg := config.NewConfig()
+		cfg.DatasetRefreshInterval = 0
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("GetCurrentDataset", ctx).Return(first, nil).Twice()
+		repositoryMock.On("GetCurrentDataset", ctx).Return(second, nil).Once()
+		repositoryMock.On("GetTimeRadiusMapData", ctx, first.Version).Return(firstMap, nil).Once()
+		repositoryMock.On("GetTimeRadiusMapData", ctx, second.Version).Return(secondMap, nil).Once()
+		repositoryMock.On("GetOverrides", ctx).Return([]entities.Override{}, nil)
+		repositoryMock.On("GetExclusions", ctx).Return(entities.Exclusions{}, nil)
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, mocks.NewRestaurantSourceMock(), nil, logs)
+		var reasons []string
+		for i := 0; i < 3; i++ {
+			response, err := service.CheckDeliverability(ctx, request)
+			assert.NoError(t, err)
+			reasons = append(reasons, response.Reason)
+		}
+
+		assert.Equal(t, []string{entities.ReasonUnknownRestaurant, entities.ReasonUnknownRestaurant,
+			entities.ReasonDeliverable}, reasons)
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("published datasets announced to the watcher", func(t *testing.T) {
+		cfg := config.NewConfig()
+		cfg.DatasetRefreshInterval = time.Hour
+		datasets := make(chan entities.Dataset)
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("GetCurrentDataset", ctx).Return(first, nil).Once()
+		repositoryMock.On("GetTimeRadiusMapData", ctx, first.Version).Return(firstMap, nil).Once()
+		repositoryMock.On("GetTimeRadiusMapData", ctx, second.Version).Return(secondMap, nil).Once()
+		repositoryMock.On("GetOverrides", ctx).Return([]entities.Override{}, nil)
+		repositoryMock.On("GetExclusions", ctx).Return(entities.Exclusions{}, nil)
+		repositoryMock.On("WatchDatasets", ctx).Return(datasets, nil)
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, mocks.NewRestaurantSourceMock(), nil, logs)
+		response, err := service.CheckDeliverability(ctx, request)
+		assert.NoError(t, err)
+		assert.False(t, response.Deliverable)
+
+		watching := make(chan struct{})
+		go func() {
+			service.WatchDatasets(ctx)
+			close(watching)
+		}()
+		datasets <- second
+		close(datasets)
+		<-watching
+
+		response, err = service.CheckDeliverability(ctx, request)
+		assert.NoError(t, err)
+		assert.True(t, response.Deliverable)
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("previous dataset served while the new one loads", func(t *testing.T) {
+		cfg := config.NewConfig()
+		cfg.DatasetRefreshInterval = time.Hour
+		datasets := make(chan entities.Dataset)
+		loading, loaded := make(chan struct{}), make(chan struct{})
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("GetCurrentDataset", ctx).Return(first, nil).Once()
+		repositoryMock.On("GetTimeRadiusMapData", ctx, first.Version).Return(firstMap, nil).Once()
+		repositoryMock.On("GetTimeRadiusMapData", ctx, second.Version).Return(secondMap, nil).Once().
+			Run(func(mock.Arguments) {
+				close(loading)
+				<-loaded
+			})
+		repositoryMock.On("GetOverrides", ctx).Return([]entities.Override{}, nil)
+		repositoryMock.On("GetExclusions", ctx).Return(entities.Exclusions{}, nil)
+		repositoryMock.On("WatchDatasets", ctx).Return(datasets, nil)
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, mocks.NewRestaurantSourceMock(), nil, logs)
+		_, err := service.CheckDeliverability(ctx, request)
+		assert.NoError(t, err)
+
+		watching := make(chan struct{})
+		go func() {
+			service.WatchDatasets(ctx)
+			close(watching)
+		}()
+		datasets <- second
+		<-loading
+
+		response, err := service.CheckDeliverability(ctx, request)
+		assert.NoError(t, err)
+		assert.False(t, response.Deliverable)
+
+		close(loaded)
+		close(datasets)
+		<-watching
+
+		response, err = service.CheckDeliverability(ctx, request)
+		assert.NoError(t, err)
+		assert.True(t, response.Deliverable)
+		repositoryMock.AssertExpectations(t)
+	})
+}
+
+func Test_CalculatorService_Overrides(t *testing.T) {
+	logs := logger.NewLogger()
+	cfg := config.NewConfig()
+	ctx := context.Background()
+	dataset := entities.Dataset{Version: "1"}
+	allDay := schedule.NewWeek(schedule.Day{Intervals: []schedule.Interval{schedule.AllDay}})
+	timeRadiusMap := entities.Restaurants{{ID: "1", Lat: 51.5, Long: -0.1, Radius: 5, Schedule: allDay}}.
+		CreateTimeRadiusMap()
+	christmas := time.Date(2023, 12, 25, 12, 0, 0, 0, time.UTC)
+	request := entities.DeliverabilityRequest{RestaurantID: "1", Lat: 51.5, Long: -0.1, Now: christmas}
+	closure := entities.Override{ID: "7", RestaurantID: "1", From: "2023-12-25", To: "2023-12-25", Hours: "closed",
+		CreatedAt: christmas}
+
+	t.Run("created overrides apply right away", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("GetCurrentDataset", ctx).Return(dataset, nil)
+		repositoryMock.On("GetTimeRadiusMapData", ctx, dataset.Version).Return(timeRadiusMap, nil).Once()
+		repositoryMock.On("GetOverrides", ctx).Return([]entities.Override{}, nil).Once()
+		repositoryMock.On("GetExclusions", ctx).Return(entities.Exclusions{}, nil)
+		repositoryMock.On("SetOverride", ctx, mock.MatchedBy(func(override entities.Override) bool {
+			return override.ID != "" && override.RestaurantID == "1" && override.Hours == "closed"
+		})).Return(nil)
+		repositoryMock.On("GetOverrides", ctx).Return([]entities.Override{closure}, nil).Once()
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, mocks.NewRestaurantSourceMock(), nil, logs)
+		response, err := service.CheckDeliverability(ctx, request)
+		assert.NoError(t, err)
+		assert.True(t, response.Deliverable)
+
+		_, err = service.CreateOverride(ctx, entities.Override{RestaurantID: "1", From: "2023-12-25",
+			To: "2023-12-25"})
+		assert.NoError(t, err)
+
+		response, err = service.CheckDeliverability(ctx, request)
+		assert.NoError(t, err)
+		assert.Equal(t, entities.ReasonClosed, response.Reason)
+
+		request.Now = christmas.AddDate(0, 0, 1)
+		response, err = service.CheckDeliverability(ctx, request)
+		assert.NoError(t, err)
+		assert.True(t, response.Deliverable)
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("stale overrides served while they are refreshed", func(t *testing.T) {
+		refreshCfg := cfg
+		refreshCfg.OverridesRefreshInterval = 0
+		request := entities.DeliverabilityRequest{RestaurantID: "1", Lat: 51.5, Long: -0.1, Now: christmas}
+		refreshing, refreshed := make(chan struct{}), make(chan struct{})
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("GetCurrentDataset", ctx).Return(dataset, nil)
+		repositoryMock.On("GetTimeRadiusMapData", ctx, dataset.Version).Return(timeRadiusMap, nil).Once()
+		repositoryMock.On("GetExclusions", ctx).Return(entities.Exclusions{}, nil)
+		repositoryMock.On("GetOverrides", ctx).Return([]entities.Override{}, nil).Once()
+		repositoryMock.On("GetOverrides", ctx).Return([]entities.Override{closure}, nil).Once().
+			Run(func(mock.Arguments) {
+				close(refreshing)
+				<-refreshed
+			})
+
+		service := calculator.NewCalculatorService(refreshCfg, repositoryMock, mocks.NewRestaurantSourceMock(), nil,
+			logs)
+		response, err := service.CheckDeliverability(ctx, request)
+		assert.NoError(t, err)
+		assert.True(t, response.Deliverable)
+
+		reasons := make(chan string)
+		go func() {
+			response, err := service.CheckDeliverability(ctx, request)
+			assert.NoError(t, err)
+			reasons <- response.Reason
+		}()
+		<-refreshing
+
+		response, err = service.CheckDeliverability(ctx, request)
+		assert.NoError(t, err)
+		assert.True(t, response.Deliverable)
+
+		close(refreshed)
+		assert.Equal(t, entities.ReasonClosed, <-reasons)
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("invalid override", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, mocks.NewRestaurantSourceMock(), nil, logs)
+		_, err := service.CreateOverride(ctx, entities.Override{RestaurantID: "1", From: "2023-12-26",
+			To: "2023-12-25"})
+
+		assert.Implements(t, (*exceptions.BadRequestException)(nil), err)
+		repositoryMock.AssertNotCalled(t, "SetOverride", mock.Anything, mock.Anything)
+	})
+
+	t.Run("list of a restaurant by start date", func(t *testing.T) {
+		newYear := entities.Override{ID: "8", RestaurantID: "1", From: "2023-12-31", To: "2024-01-01",
+			Hours: "12:00-16:00"}
+		otherRestaurant := entities.Override{ID: "9", RestaurantID: "2", From: "2023-12-24", To: "2023-12-24",
+			Hours: "closed"}
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("GetOverrides", ctx).Return([]entities.Override{newYear, otherRestaurant, closure}, nil)
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, mocks.NewRestaurantSourceMock(), nil, logs)
+		overrides, err := service.ListOverrides(ctx, "1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []entities.Override{closure, newYear}, overrides)
+	})
+
+	t.Run("unknown override", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("DeleteOverride", ctx, "7").Return(false, nil)
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, mocks.NewRestaurantSourceMock(), nil, logs)
+		err := service.DeleteOverride(ctx, "7")
+
+		assert.Implements(t, (*exceptions.NotFoundException)(nil), err)
+	})
+}
+
+func Test_CalculatorService_Exclusions(t *testing.T) {
+	logs := logger.NewLogger()
+	cfg := config.NewConfig()
+	ctx := context.Background()
+	dataset := entities.Dataset{Version: "1"}
+	allDay := schedule.NewWeek(schedule.Day{Intervals: []schedule.Interval{schedule.AllDay}})
+	timeRadiusMap := entities.Restaurants{{ID: "1", Lat: 51.47, Long: -0.4, Radius: 5, Schedule: allDay}}.
+		CreateTimeRadiusMap()
+	var airport entities.Exclusion
+	require.NoError(t, json.Unmarshal([]byte(`{"id": "1", "name": "airport", "zone": {"type": "Polygon",
+		"coordinates": [[[-0.49, 51.46], [-0.42, 51.46], [-0.42, 51.48], [-0.49, 51.48], [-0.49, 51.46]]]}}`),
+		&airport))
+	now := time.Date(2023, 12, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("excluded point skips the candidate search", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("GetExclusions", ctx).Return(entities.Exclusions{airport}, nil).Once()
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, mocks.NewRestaurantSourceMock(), nil, logs)
+		for i := 0; i < 2; i++ {
+			response, err := service.CalculateDeliveryRange(ctx, entities.CalculationRequest{Now: now, Lat: 51.47,
+				Long: -0.45})
+
+			assert.NoError(t, err)
+			assert.Equal(t, []string{}, response.RestaurantIDs)
+			assert.Equal(t, entities.ReasonExclusionZone, response.Reason)
+		}
+		repositoryMock.AssertExpectations(t)
+		repositoryMock.AssertNotCalled(t, "GetCurrentDataset", mock.Anything)
+		repositoryMock.AssertNotCalled(t, "GetRestaurantsInRadius", mock.Anything, mock.Anything, mock.Anything,
+			mock.Anything, mock.Anything)
+	})
+
+	t.Run("batch locations and deliverability", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("GetExclusions", ctx).Return(entities.Exclusions{airport}, nil)
+		repositoryMock.On("GetCurrentDataset", ctx).Return(dataset, nil)
+		repositoryMock.On("GetTimeRadiusMapData", ctx, dataset.Version).Return(timeRadiusMap, nil)
+		repositoryMock.On("GetOverrides", ctx).Return([]entities.Override{}, nil)
+		repositoryMock.On("GetRestaurantsInRadius", ctx, dataset.Version, 51.47, -0.4, cfg.MaxDeliveryRadius).
+			Return([]entities.RestaurantIDLatLng{{ID: "1", Lat: 51.47, Long: -0.4}}, nil)
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, mocks.NewRestaurantSourceMock(), nil, logs)
+		response, err := service.CalculateBatchDeliveryRange(ctx, []entities.BatchLocation{
+			{ID: "terminal", Lat: 51.47, Long: -0.45, Now: now},
+			{ID: "village", Lat: 51.47, Long: -0.4, Now: now},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]entities.BatchLocationResult{
+			"terminal": {RestaurantIDs: []string{}, Reason: entities.ReasonExclusionZone},
+			"village":  {RestaurantIDs: []string{"1"}},
+		}, response.Results)
+
+		deliverability, err := service.CheckDeliverability(ctx, entities.DeliverabilityRequest{RestaurantID: "1",
+			Now: now, Lat: 51.47, Long: -0.45})
+		assert.NoError(t, err)
+		assert.False(t, deliverability.Deliverable)
+		assert.Equal(t, entities.ReasonExclusionZone, deliverability.Reason)
+	})
+
+	t.Run("created exclusions apply right away", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("GetExclusions", ctx).Return(entities.Exclusions{}, nil).Once()
+		repositoryMock.On("GetCurrentDataset", ctx).Return(dataset, nil)
+		repositoryMock.On("GetTimeRadiusMapData", ctx, dataset.Version).Return(timeRadiusMap, nil)
+		repositoryMock.On("GetOverrides", ctx).Return([]entities.Override{}, nil)
+		repositoryMock.On("SetExclusion", ctx, mock.MatchedBy(func(exclusion entities.Exclusion) bool {
+			return exclusion.ID != "" && exclusion.Name == "airport"
+		})).Return(nil)
+		repositoryMock.On("GetExclusions", ctx).Return(entities.Exclusions{airport}, nil).Once()
+		request := entities.DeliverabilityRequest{RestaurantID: "1", Now: now, Lat: 51.47, Long: -0.45}
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, mocks.NewRestaurantSourceMock(), nil, logs)
+		response, err := service.CheckDeliverability(ctx, request)
+		assert.NoError(t, err)
+		assert.True(t, response.Deliverable)
+
+		_, err = service.CreateExclusion(ctx, entities.Exclusion{Name: "airport", Zone: airport.Zone})
+		assert.NoError(t, err)
+
+		response, err = service.CheckDeliverability(ctx, request)
+		assert.NoError(t, err)
+		assert.Equal(t, entities.ReasonExclusionZone, response.Reason)
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("exclusions created during a refresh apply right after it", func(t *testing.T) {
+		refreshing, refreshed := make(chan struct{}), make(chan struct{})
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("GetExclusions", ctx).Return(entities.Exclusions{}, nil).Once().
+			Run(func(mock.Arguments) {
+				close(refreshing)
+				<-refreshed
+			})
+		repositoryMock.On("GetCurrentDataset", ctx).Return(dataset, nil)
+		repositoryMock.On("GetTimeRadiusMapData", ctx, dataset.Version).Return(timeRadiusMap, nil)
+		repositoryMock.On("GetOverrides", ctx).Return([]entities.Override{}, nil)
+		repositoryMock.On("SetExclusion", ctx, mock.AnythingOfType("entities.Exclusion")).Return(nil)
+		repositoryMock.On("GetExclusions", ctx).Return(entities.Exclusions{airport}, nil).Once()
+		request := entities.DeliverabilityRequest{RestaurantID: "1", Now: now, Lat: 51.47, Long: -0.45}
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, mocks.NewRestaurantSourceMock(), nil, logs)
+		deliverable := make(chan bool)
+		go func() {
+			response, err := service.CheckDeliverability(ctx, request)
+			assert.NoError(t, err)
+			deliverable <- response.Deliverable
+		}()
+		<-refreshing
+
+		_, err := service.CreateExclusion(ctx, entities.Exclusion{Name: "airport", Zone: airport.Zone})
+		assert.NoError(t, err)
+		close(refreshed)
+		assert.True(t, <-deliverable)
+
+		response, err := service.CheckDeliverability(ctx, request)
+		assert.NoError(t, err)
+		assert.Equal(t, entities.ReasonExclusionZone, response.Reason)
+		repositoryMock.AssertExpectations(t)
+	})
+
+	t.Run("invalid exclusion", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, mocks.NewRestaurantSourceMock(), nil, logs)
+		_, err := service.CreateExclusion(ctx, entities.Exclusion{Name: "airport"})
+
+		assert.Implements(t, (*exceptions.BadRequestException)(nil), err)
+		repositoryMock.AssertNotCalled(t, "SetExclusion", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown exclusion", func(t *testing.T) {
+		repositoryMock := mocks.NewCalculatorRepositoryMock()
+		repositoryMock.On("DeleteExclusion", ctx, "7").Return(false, nil)
+
+		service := calculator.NewCalculatorService(cfg, repositoryMock, mocks.NewRestaurantSourceMock(), nil, logs)
+		err := service.DeleteExclusion(ctx, "7")
+
+		assert.Implements(t, (*exceptions.NotFoundException)(nil), err)
+	})
+}
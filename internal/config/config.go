@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/kelseyhightower/envconfig"
 )
 
@@ -13,8 +15,58 @@ type (
 		Env            string `envconfig:"ENV" default:"prod"`
 		Redis          struct {
 			Host string `envconfig:"REDIS_HOST" default:"127.0.0.1:6379"`
+			// members or fields per GEOADD and HSET of bulk writes
+			GeoAddChunkSize int `envconfig:"REDIS_GEO_ADD_CHUNK_SIZE" default:"500"`
+		}
+		Source struct {
+			Type     string `envconfig:"RESTAURANTS_SOURCE_TYPE" default:"http"`
+			URL      string `envconfig:"RESTAURANTS_SOURCE_URL" default:"https://s3.amazonaws.com/test.jampp.com/dmarasca/takehome.csv"`
+			FilePath string `envconfig:"RESTAURANTS_SOURCE_FILE_PATH"`
+			S3       struct {
+				Endpoint  string `envconfig:"RESTAURANTS_SOURCE_S3_ENDPOINT" default:"https://s3.amazonaws.com"`
+				Region    string `envconfig:"RESTAURANTS_SOURCE_S3_REGION" default:"us-east-1"`
+				Bucket    string `envconfig:"RESTAURANTS_SOURCE_S3_BUCKET"`
+				Key       string `envconfig:"RESTAURANTS_SOURCE_S3_KEY"`
+				AccessKey string `envconfig:"RESTAURANTS_SOURCE_S3_ACCESS_KEY"`
+				SecretKey string `envconfig:"RESTAURANTS_SOURCE_S3_SECRET_KEY"`
+				ZonesKey  string `envconfig:"RESTAURANTS_ZONES_SOURCE_S3_KEY"`
+			}
+			// location of the optional GeoJSON delivery zones file, read from a source of the same type
+			ZonesURL      string `envconfig:"RESTAURANTS_ZONES_SOURCE_URL"`
+			ZonesFilePath string `envconfig:"RESTAURANTS_ZONES_SOURCE_FILE_PATH"`
 		}
+		Preprocess struct {
+			BatchSize             int `envconfig:"PREPROCESS_BATCH_SIZE" default:"1000"`
+			ProgressInterval      int `envconfig:"PREPROCESS_PROGRESS_INTERVAL" default:"100000"`
+			MaxReportedRejections int `envconfig:"PREPROCESS_MAX_REPORTED_REJECTIONS" default:"1000"`
+			// IANA time zone of the restaurants without a timezone column value
+			DefaultTimeZone string `envconfig:"RESTAURANTS_TIME_ZONE" default:"UTC"`
+			// canonical column name to accepted header names, e.g. latitude:lat|lat_deg,longitude:lng
+			ColumnAliases map[string]string `envconfig:"CSV_COLUMN_ALIASES"`
+		}
+		// redis, or memory to serve queries from an in-process spatial index of the current dataset
+		RepositoryBackend string `envconfig:"REPOSITORY_BACKEND" default:"redis"`
+		// how long the current dataset is served from memory before checking the pointer, new datasets are also
+		// announced to every replica through Redis pub/sub
+		DatasetRefreshInterval time.Duration `envconfig:"DATASET_REFRESH_INTERVAL" default:"30s"`
+		// how long schedule overrides are served from memory before loading them again
+		OverridesRefreshInterval time.Duration `envconfig:"OVERRIDES_REFRESH_INTERVAL" default:"30s"`
+		// how long delivery exclusion zones are served from memory before loading them again
+		ExclusionsRefreshInterval time.Duration `envconfig:"EXCLUSIONS_REFRESH_INTERVAL" default:"30s"`
+		// search radius of datasets published without their max radius, newer datasets store their own
 		MaxDeliveryRadius float64 `envconfig:"MAX_DELIVERY_RADIUS" default:"6"`
+		// how far in the future availability can be queried for scheduled orders
+		ScheduleHorizon time.Duration `envconfig:"SCHEDULE_HORIZON" default:"72h"`
+		MaxPageSize     int           `envconfig:"MAX_PAGE_SIZE" default:"100"`
+		// how long after the first page the next ones can be requested with its cursor
+		CursorTTL time.Duration `envconfig:"CURSOR_TTL" default:"15m"`
+		// token internal callers send in the X-Internal-Token header to use debug options, empty disables them
+		InternalAPIToken string `envconfig:"INTERNAL_API_TOKEN"`
+		Batch            struct {
+			// concurrent geo searches of a single batch request
+			Workers      int `envconfig:"BATCH_WORKERS" default:"8"`
+			MaxLocations int `envconfig:"BATCH_MAX_LOCATIONS" default:"100"`
+		}
 	}
 )
 
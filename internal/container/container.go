@@ -1,13 +1,15 @@
 package container
 
 import (
+	"context"
+
 	"github.com/go-resty/resty/v2"
 	"github.com/sebastianreh/distance-calculator-api/internal/app/calculator"
 	"github.com/sebastianreh/distance-calculator-api/internal/app/ping"
 	"github.com/sebastianreh/distance-calculator-api/internal/config"
 	"github.com/sebastianreh/distance-calculator-api/pkg/logger"
 	rds "github.com/sebastianreh/distance-calculator-api/pkg/redis"
-	"github.com/sebastianreh/distance-calculator-api/pkg/rest"
+	"github.com/sebastianreh/distance-calculator-api/pkg/source"
 )
 
 type Dependencies struct {
@@ -30,9 +32,24 @@ func Build() Dependencies {
 	}
 
 	restyClient := resty.New()
-	s3RestClient := rest.NewS3Client(logs, restyClient)
-	calculatorRepository := calculator.NewCalculatorRepository(dependencies.Config, redis, logs)
-	calculatorService := calculator.NewCalculatorService(dependencies.Config, calculatorRepository, s3RestClient, logs)
+	restaurantSource, err := source.NewRestaurantSource(dependencies.Config, logs, restyClient)
+	if err != nil {
+		logs.Fatal(err.Error())
+	}
+
+	zonesSource, err := source.NewZonesSource(dependencies.Config, logs, restyClient)
+	if err != nil {
+		logs.Fatal(err.Error())
+	}
+
+	calculatorRepository, err := calculator.NewCalculatorRepositoryForBackend(dependencies.Config, redis, logs)
+	if err != nil {
+		logs.Fatal(err.Error())
+	}
+
+	calculatorService := calculator.NewCalculatorService(dependencies.Config, calculatorRepository, restaurantSource,
+		zonesSource, logs)
+	go calculatorService.WatchDatasets(context.Background())
 	calculatorHandler := calculator.NewCalculatorHandler(dependencies.Config, calculatorService, logs)
 
 	dependencies.CalculatorHandler = calculatorHandler
@@ -0,0 +1,61 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+)
+
+// BatchLocation is one customer point of a batch availability query, At is an optional RFC 3339 time
+type BatchLocation struct {
+	ID   string    `json:"id"`
+	Lat  float64   `json:"lat"`
+	Long float64   `json:"long"`
+	At   string    `json:"at,omitempty"`
+	Now  time.Time `json:"-"`
+}
+
+// BatchLocationResult holds the available restaurants of a location, or why they could not be calculated
+type BatchLocationResult struct {
+	RestaurantIDs []string `json:"restaurant_ids"`
+	Error         string   `json:"error,omitempty"`
+	Reason        string   `json:"reason,omitempty"`
+}
+
+type BatchCalculationResponse struct {
+	Results map[string]BatchLocationResult `json:"results"`
+}
+
+// ValidateBatchLocations checks a batch is not empty, within the max size and that location ids are unique
+func ValidateBatchLocations(locations []BatchLocation, maxLocations int) error {
+	if len(locations) == 0 {
+		return fmt.Errorf("at least one location is required")
+	}
+	if len(locations) > maxLocations {
+		return fmt.Errorf("at most %d locations are allowed", maxLocations)
+	}
+
+	ids := make(map[string]struct{}, len(locations))
+	for _, location := range locations {
+		if location.ID == "" {
+			return fmt.Errorf("every location requires an id")
+		}
+		if _, duplicated := ids[location.ID]; duplicated {
+			return fmt.Errorf("location id %s appears more than once", location.ID)
+		}
+		ids[location.ID] = struct{}{}
+	}
+
+	return nil
+}
+
+func (location BatchLocation) CalculationRequest() CalculationRequest {
+	return CalculationRequest{
+		Now:  location.Now,
+		Lat:  location.Lat,
+		Long: location.Long,
+	}
+}
+
+func NewBatchCalculationResponse(size int) BatchCalculationResponse {
+	return BatchCalculationResponse{Results: make(map[string]BatchLocationResult, size)}
+}
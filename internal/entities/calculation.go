@@ -1,19 +1,54 @@
 package entities
 
 import (
+	"math"
 	"time"
 
+	"github.com/sebastianreh/distance-calculator-api/pkg/geozone"
 	mathFormulas "github.com/sebastianreh/distance-calculator-api/pkg/math_formulas"
+	"github.com/sebastianreh/distance-calculator-api/pkg/schedule"
+)
+
+const (
+	ExpandDetails = "details"
+	// distances are reported with meter precision
+	distancePrecision = 1000
 )
 
 type CalculationRequest struct {
-	Now  time.Time
-	Lat  float64 `query:"lat"`
-	Long float64 `query:"long"`
+	Now    time.Time
+	Lat    float64 `query:"lat"`
+	Long   float64 `query:"long"`
+	Expand string  `query:"expand"`
+	Sort   string  `query:"sort"`
+	Limit  int     `query:"limit"`
+	Cursor string  `query:"cursor"`
+	// MinRating and Attributes filter the restaurants, Attributes as attribute:value pairs parsed into AttributeFilters
+	MinRating        float64  `query:"min_rating"`
+	Attributes       []string `query:"attr"`
+	AttributeFilters AttributeFilters
+	// Debug=explain lists every candidate with the stage that eliminated it, restricted to internal callers
+	Debug string `query:"debug"`
 }
 
 type CalculationResponse struct {
-	RestaurantIDs []string `json:"restaurant_ids"`
+	RestaurantIDs []string            `json:"restaurant_ids"`
+	Restaurants   []RestaurantDetails `json:"restaurants,omitempty"`
+	NextCursor    string              `json:"next_cursor,omitempty"`
+	Explanation   *Explanation        `json:"explanation,omitempty"`
+	// Reason tells why no restaurant is returned regardless of the dataset, e.g. an exclusion zone
+	Reason string `json:"reason,omitempty"`
+}
+
+// RestaurantDetails describes a restaurant able to deliver, returned when the request expands details
+type RestaurantDetails struct {
+	ID                  string  `json:"id"`
+	DistanceKm          float64 `json:"distance_km"`
+	Rating              float64 `json:"rating"`
+	DeliveryRadiusKm    float64 `json:"delivery_radius_km"`
+	OpensAt             string  `json:"opens_at"`
+	ClosesAt            string  `json:"closes_at"`
+	MinutesUntilClosing int     `json:"minutes_until_closing"`
 }
 
 type RestaurantIDLatLng struct {
@@ -27,52 +62,114 @@ type (
 	TimeRadiusMap map[string]timeRadiusSchedule
 
 	timeRadiusSchedule struct {
-		Open   int     `json:"open"`
-		Close  int     `json:"close"`
-		Radius float64 `json:"radius"`
+		Lat      float64           `json:"lat"`
+		Long     float64           `json:"long"`
+		Schedule schedule.Week     `json:"schedule"`
+		TimeZone string            `json:"time_zone,omitempty"`
+		Radius   float64           `json:"radius"`
+		Rating   float64           `json:"rating"`
+		Metadata map[string]string `json:"metadata,omitempty"`
+
+		RadiusRules []RadiusRule  `json:"radius_rules,omitempty"`
+		Zone        *geozone.Zone `json:"zone,omitempty"`
+		ZoneRadius  float64       `json:"zone_radius,omitempty"`
+	}
+
+	// openRestaurant is a candidate that is open at the request time, with the interval it is open in
+	openRestaurant struct {
+		RestaurantIDLatLng
+		rating              float64
+		interval            schedule.Interval
+		minutesUntilClosing int
+		zone                *geozone.Zone
 	}
 )
 
-func (request CalculationRequest) FindRestaurantsInRadius(timeRadiusMap TimeRadiusMap,
-	restaurantInUserRadius []RestaurantIDLatLng) []string {
-	openRestaurants := findOpenRestaurants(request, timeRadiusMap, restaurantInUserRadius)
+func (request CalculationRequest) ExpandsDetails() bool {
+	return request.Expand == ExpandDetails
+}
+
+// NewCalculationResponse lists the ids of the restaurants, and their details when the request expands them
+func (request CalculationRequest) NewCalculationResponse(restaurants []RestaurantDetails) CalculationResponse {
+	response := CalculationResponse{RestaurantIDs: make([]string, 0, len(restaurants))}
+	for _, restaurant := range restaurants {
+		response.RestaurantIDs = append(response.RestaurantIDs, restaurant.ID)
+	}
+	if request.ExpandsDetails() {
+		response.Restaurants = append(make([]RestaurantDetails, 0, len(restaurants)), restaurants...)
+	}
+
+	return response
+}
+
+// FindRestaurantsInRadius keeps the candidates matching the filters, open at the request time with their overrides
+// applied, and delivering to the request location
+func (request CalculationRequest) FindRestaurantsInRadius(timeRadiusMap TimeRadiusMap, overrides Overrides,
+	restaurantInUserRadius []RestaurantIDLatLng) []RestaurantDetails {
+	matchingRestaurants := findRestaurantsMatchingFilters(request, timeRadiusMap, restaurantInUserRadius)
+	openRestaurants := findOpenRestaurants(request, timeRadiusMap, overrides, matchingRestaurants)
 	inRadius := findRestaurantsWithinDeliveryRadius(openRestaurants, request)
 
 	return inRadius
 }
 
-func findOpenRestaurants(request CalculationRequest, timeRadiusMap TimeRadiusMap,
+func findRestaurantsMatchingFilters(request CalculationRequest, timeRadiusMap TimeRadiusMap,
 	restaurants []RestaurantIDLatLng) []RestaurantIDLatLng {
-	var openRestaurants []RestaurantIDLatLng
+	if request.MinRating <= 0 && len(request.AttributeFilters) == 0 {
+		return restaurants
+	}
+
+	var matchingRestaurants []RestaurantIDLatLng
 	for _, restaurant := range restaurants {
 		timeRadius, ok := timeRadiusMap[restaurant.ID]
-		if ok {
-			currentTime := request.Now.Hour()*100 + request.Now.Minute()
-			// validates if is still Open after midnight
-			if timeRadius.Open > timeRadius.Close {
-				if currentTime >= timeRadius.Open && currentTime < timeRadius.Close {
-					restaurant.DeliveryRadius = timeRadius.Radius
-					openRestaurants = append(openRestaurants, restaurant)
-				}
-			} else {
-				if currentTime >= timeRadius.Open || currentTime < timeRadius.Close {
-					restaurant.DeliveryRadius = timeRadius.Radius
-					openRestaurants = append(openRestaurants, restaurant)
-				}
-			}
+		if ok && request.matchesFilters(timeRadius) {
+			matchingRestaurants = append(matchingRestaurants, restaurant)
+		}
+	}
+
+	return matchingRestaurants
+}
+
+func findOpenRestaurants(request CalculationRequest, timeRadiusMap TimeRadiusMap, overrides Overrides,
+	restaurants []RestaurantIDLatLng) []openRestaurant {
+	var openRestaurants []openRestaurant
+	for _, restaurant := range restaurants {
+		timeRadius, ok := timeRadiusMap[restaurant.ID]
+		if !ok {
+			continue
+		}
+
+		interval, minutesUntilClosing, open := timeRadius.openInterval(request.Now, overrides[restaurant.ID])
+		if open {
+			restaurant.DeliveryRadius = timeRadius.radiusAt(request.Now)
+			openRestaurants = append(openRestaurants, openRestaurant{
+				RestaurantIDLatLng:  restaurant,
+				rating:              timeRadius.Rating,
+				interval:            interval,
+				minutesUntilClosing: minutesUntilClosing,
+				zone:                timeRadius.Zone,
+			})
 		}
 	}
 
 	return openRestaurants
 }
 
-func findRestaurantsWithinDeliveryRadius(restaurants []RestaurantIDLatLng, request CalculationRequest) []string {
-	withinDeliveryRadius := make([]string, 0)
+func findRestaurantsWithinDeliveryRadius(restaurants []openRestaurant, request CalculationRequest) []RestaurantDetails {
+	withinDeliveryRadius := make([]RestaurantDetails, 0)
 
 	for _, restaurant := range restaurants {
 		distance := mathFormulas.Haversine(request.Lat, request.Long, restaurant.Lat, restaurant.Long)
-		if distance <= restaurant.DeliveryRadius {
-			withinDeliveryRadius = append(withinDeliveryRadius, restaurant.ID)
+		if deliversTo(restaurant.zone, restaurant.DeliveryRadius, request.Lat, request.Long, distance) {
+			withinDeliveryRadius = append(withinDeliveryRadius, RestaurantDetails{
+				ID:                  restaurant.ID,
+				DistanceKm:          math.Round(distance*distancePrecision) / distancePrecision,
+				Rating:              restaurant.rating,
+				DeliveryRadiusKm:    restaurant.DeliveryRadius,
+				OpensAt:             restaurant.interval.Open.String(),
+				ClosesAt:            restaurant.interval.Close.String(),
+				MinutesUntilClosing: restaurant.minutesUntilClosing,
+			})
 		}
 	}
 
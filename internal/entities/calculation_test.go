@@ -0,0 +1,100 @@
+package entities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/sebastianreh/distance-calculator-api/pkg/schedule"
+	"github.com/stretchr/testify/assert"
+)
+
+// everyDay is a weekly schedule with the same hours, in the notation of schedule.ParseDay, every day
+func everyDay(hours string) schedule.Week {
+	day, err := schedule.ParseDay(hours)
+	if err != nil {
+		panic(err)
+	}
+	return schedule.NewWeek(day)
+}
+
+func Test_CalculationRequest_FindRestaurantsInRadius(t *testing.T) {
+	restaurants := entities.Restaurants{
+		{ID: "buenos-aires", Lat: -34.6037, Long: -58.3816, Radius: 5, TimeZone: "America/Argentina/Buenos_Aires",
+			Schedule: everyDay("19:00-23:30")},
+		{ID: "new-york", Lat: -34.6037, Long: -58.3816, Radius: 5, TimeZone: "America/New_York",
+			Schedule: everyDay("19:00-23:30")},
+	}
+	candidates := []entities.RestaurantIDLatLng{
+		{ID: "buenos-aires", Lat: -34.6037, Long: -58.3816},
+		{ID: "new-york", Lat: -34.6037, Long: -58.3816},
+	}
+
+	t.Run("opening hours evaluated in each restaurant time zone", func(t *testing.T) {
+		// 20:00 in Buenos Aires (UTC-3), 19:00 in New York (UTC-4 during DST)
+		request := entities.CalculationRequest{Lat: -34.6037, Long: -58.3816,
+			Now: time.Date(2023, 10, 17, 23, 0, 0, 0, time.UTC)}
+
+		response := request.NewCalculationResponse(request.FindRestaurantsInRadius(restaurants.CreateTimeRadiusMap(), nil, candidates))
+
+		assert.ElementsMatch(t, []string{"buenos-aires", "new-york"}, response.RestaurantIDs)
+		assert.Nil(t, response.Restaurants)
+	})
+
+	t.Run("daylight saving time change", func(t *testing.T) {
+		// New York is back to UTC-5 in December: 23:00 UTC is 18:00 there
+		request := entities.CalculationRequest{Lat: -34.6037, Long: -58.3816,
+			Now: time.Date(2023, 12, 5, 23, 0, 0, 0, time.UTC)}
+
+		response := request.NewCalculationResponse(request.FindRestaurantsInRadius(restaurants.CreateTimeRadiusMap(), nil, candidates))
+
+		assert.Equal(t, []string{"buenos-aires"}, response.RestaurantIDs)
+	})
+
+	t.Run("expanded details", func(t *testing.T) {
+		detailed := entities.Restaurants{
+			{ID: "1", Lat: 51.5074, Long: -0.1278, Radius: 5, Rating: 4.5,
+				Schedule: everyDay("19:00-01:00")},
+		}
+		request := entities.CalculationRequest{Lat: 51.5174, Long: -0.1278, Expand: entities.ExpandDetails,
+			Now: time.Date(2023, 10, 17, 22, 15, 0, 0, time.UTC)}
+
+		response := request.NewCalculationResponse(request.FindRestaurantsInRadius(detailed.CreateTimeRadiusMap(), nil,
+			[]entities.RestaurantIDLatLng{{ID: "1", Lat: 51.5074, Long: -0.1278}}))
+
+		assert.Equal(t, []string{"1"}, response.RestaurantIDs)
+		assert.Equal(t, []entities.RestaurantDetails{{ID: "1", DistanceKm: 1.112, Rating: 4.5, DeliveryRadiusKm: 5,
+			OpensAt: "19:00", ClosesAt: "01:00", MinutesUntilClosing: 165}}, response.Restaurants)
+	})
+
+	t.Run("rating and attribute filters", func(t *testing.T) {
+		allDay := everyDay("24h")
+		filtered := entities.Restaurants{
+			{ID: "1", Radius: 5, Rating: 4.5, Schedule: allDay, Metadata: map[string]string{"cuisine": "Italian"}},
+			{ID: "2", Radius: 5, Rating: 3.5, Schedule: allDay, Metadata: map[string]string{"cuisine": "italian"}},
+			{ID: "3", Radius: 5, Rating: 4.8, Schedule: allDay, Metadata: map[string]string{"cuisine": "sushi"}},
+			{ID: "4", Radius: 5, Rating: 4.9, Schedule: allDay},
+		}
+		filteredCandidates := []entities.RestaurantIDLatLng{{ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}}
+		filters, err := entities.ParseAttributeFilters([]string{"Cuisine:italian", "cuisine:SUSHI"})
+		assert.NoError(t, err)
+		request := entities.CalculationRequest{MinRating: 4, AttributeFilters: filters,
+			Now: time.Date(2023, 10, 17, 12, 0, 0, 0, time.UTC)}
+
+		response := request.NewCalculationResponse(request.FindRestaurantsInRadius(filtered.CreateTimeRadiusMap(), nil,
+			filteredCandidates))
+
+		assert.ElementsMatch(t, []string{"1", "3"}, response.RestaurantIDs)
+	})
+}
+
+func Test_ParseAttributeFilters(t *testing.T) {
+	filters, err := entities.ParseAttributeFilters([]string{"cuisine:italian", "price_tier: 2", "cuisine:pizza"})
+	assert.NoError(t, err)
+	assert.Equal(t, entities.AttributeFilters{"cuisine": {"italian", "pizza"}, "price_tier": {"2"}}, filters)
+
+	for _, rawFilter := range []string{"cuisine", ":italian", "cuisine:"} {
+		_, err = entities.ParseAttributeFilters([]string{rawFilter})
+		assert.Error(t, err, rawFilter)
+	}
+}
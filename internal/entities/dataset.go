@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"strconv"
+	"time"
+)
+
+// Redis measures geo distances with a slightly larger earth radius (6372.8 km) than Haversine (6371 km), and
+// stores coordinates as 52 bit geohashes, so the search radius gets a small margin to never miss a restaurant
+// right at the edge of its delivery radius
+const searchRadiusMargin = 1.001
+
+// Dataset identifies one preprocessed snapshot of the restaurants feed. Every key written while
+// preprocessing is suffixed with its version, so readers always see a single consistent snapshot.
+type Dataset struct {
+	Version   string    `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// MaxRadius is the largest delivery radius of the dataset, candidates are searched within it
+	MaxRadius float64 `json:"max_radius,omitempty"`
+}
+
+func NewDataset(now time.Time) Dataset {
+	return Dataset{
+		Version:   strconv.FormatInt(now.UnixNano(), 10),
+		CreatedAt: now.UTC(),
+	}
+}
+
+func (d Dataset) IsEmpty() bool {
+	return d.Version == ""
+}
+
+// TrackRadius keeps the largest delivery radius seen while preprocessing
+func (d *Dataset) TrackRadius(radius float64) {
+	if radius > d.MaxRadius {
+		d.MaxRadius = radius
+	}
+}
+
+// SearchRadius is the radius candidates must be searched within to find every restaurant able to deliver.
+// Datasets published before the max radius was stored fall back to the given radius.
+func (d Dataset) SearchRadius(fallback float64) float64 {
+	if d.MaxRadius <= 0 {
+		return fallback
+	}
+	return d.MaxRadius * searchRadiusMargin
+}
@@ -0,0 +1,27 @@
+package entities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Dataset_SearchRadius(t *testing.T) {
+	t.Run("largest tracked radius with margin", func(t *testing.T) {
+		dataset := entities.NewDataset(time.Now())
+		for _, radius := range []float64{3, 12.5, 4} {
+			dataset.TrackRadius(radius)
+		}
+
+		assert.Equal(t, 12.5, dataset.MaxRadius)
+		assert.InDelta(t, 12.5125, dataset.SearchRadius(6), 1e-9)
+	})
+
+	t.Run("datasets without max radius fall back", func(t *testing.T) {
+		dataset := entities.Dataset{Version: "1"}
+
+		assert.Equal(t, float64(6), dataset.SearchRadius(6))
+	})
+}
@@ -0,0 +1,66 @@
+package entities
+
+import (
+	"math"
+	"time"
+
+	mathFormulas "github.com/sebastianreh/distance-calculator-api/pkg/math_formulas"
+)
+
+// Deliverability reasons, why a restaurant can or cannot deliver to a customer
+const (
+	ReasonDeliverable           = "deliverable"
+	ReasonUnknownRestaurant     = "unknown_restaurant"
+	ReasonClosed                = "closed"
+	ReasonOutOfDeliveryRadius   = "out_of_delivery_radius"
+	ReasonBeyondMaxSearchRadius = "beyond_max_search_radius"
+	ReasonExclusionZone         = "exclusion_zone"
+)
+
+type DeliverabilityRequest struct {
+	RestaurantID string `param:"id"`
+	Now          time.Time
+	Lat          float64 `query:"lat"`
+	Long         float64 `query:"long"`
+}
+
+type DeliverabilityResponse struct {
+	RestaurantID string   `json:"restaurant_id"`
+	Deliverable  bool     `json:"deliverable"`
+	Reason       string   `json:"reason"`
+	DistanceKm   *float64 `json:"distance_km,omitempty"`
+}
+
+// CheckDeliverability tells if the restaurant can deliver to the request location at the request time, applying
+// the same checks as FindRestaurantsInRadius plus the max search radius the candidates are looked up with
+func (request DeliverabilityRequest) CheckDeliverability(timeRadiusMap TimeRadiusMap, overrides Overrides,
+	maxSearchRadius float64) DeliverabilityResponse {
+	response := DeliverabilityResponse{RestaurantID: request.RestaurantID, Reason: ReasonUnknownRestaurant}
+	timeRadius, ok := timeRadiusMap[request.RestaurantID]
+	if !ok {
+		return response
+	}
+
+	distance := mathFormulas.Haversine(request.Lat, request.Long, timeRadius.Lat, timeRadius.Long)
+	roundedDistance := math.Round(distance*distancePrecision) / distancePrecision
+	response.DistanceKm = &roundedDistance
+
+	calculationRequest := CalculationRequest{Now: request.Now, Lat: request.Lat, Long: request.Long}
+	openRestaurants := findOpenRestaurants(calculationRequest, timeRadiusMap, overrides, []RestaurantIDLatLng{
+		{ID: request.RestaurantID, Lat: timeRadius.Lat, Long: timeRadius.Long},
+	})
+
+	switch {
+	case len(openRestaurants) == 0:
+		response.Reason = ReasonClosed
+	case !deliversTo(openRestaurants[0].zone, openRestaurants[0].DeliveryRadius, request.Lat, request.Long, distance):
+		response.Reason = ReasonOutOfDeliveryRadius
+	case distance > maxSearchRadius:
+		response.Reason = ReasonBeyondMaxSearchRadius
+	default:
+		response.Deliverable = true
+		response.Reason = ReasonDeliverable
+	}
+
+	return response
+}
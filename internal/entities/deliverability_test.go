@@ -0,0 +1,74 @@
+package entities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DeliverabilityRequest_CheckDeliverability(t *testing.T) {
+	dinner := everyDay("19:00-23:30")
+	timeRadiusMap := entities.Restaurants{
+		{ID: "1", Lat: 51.5074, Long: -0.1278, Radius: 5, Schedule: dinner},
+		{ID: "2", Lat: 51.5074, Long: -0.1278, Radius: 20, Schedule: dinner},
+	}.CreateTimeRadiusMap()
+	evening := time.Date(2023, 10, 17, 20, 0, 0, 0, time.UTC)
+	const maxSearchRadius = 6
+
+	tests := []struct {
+		name        string
+		request     entities.DeliverabilityRequest
+		deliverable bool
+		reason      string
+		distanceKm  *float64
+	}{
+		{
+			name:        "deliverable",
+			request:     entities.DeliverabilityRequest{RestaurantID: "1", Lat: 51.5174, Long: -0.1278, Now: evening},
+			deliverable: true,
+			reason:      entities.ReasonDeliverable,
+			distanceKm:  distance(1.112),
+		},
+		{
+			name:    "unknown restaurant",
+			request: entities.DeliverabilityRequest{RestaurantID: "3", Lat: 51.5174, Long: -0.1278, Now: evening},
+			reason:  entities.ReasonUnknownRestaurant,
+		},
+		{
+			name: "closed",
+			request: entities.DeliverabilityRequest{RestaurantID: "1", Lat: 51.5174, Long: -0.1278,
+				Now: evening.Add(-2 * time.Hour)},
+			reason:     entities.ReasonClosed,
+			distanceKm: distance(1.112),
+		},
+		{
+			name:       "out of delivery radius",
+			request:    entities.DeliverabilityRequest{RestaurantID: "1", Lat: 51.5974, Long: -0.1278, Now: evening},
+			reason:     entities.ReasonOutOfDeliveryRadius,
+			distanceKm: distance(10.008),
+		},
+		{
+			name:       "beyond max search radius",
+			request:    entities.DeliverabilityRequest{RestaurantID: "2", Lat: 51.5974, Long: -0.1278, Now: evening},
+			reason:     entities.ReasonBeyondMaxSearchRadius,
+			distanceKm: distance(10.008),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := tt.request.CheckDeliverability(timeRadiusMap, nil, maxSearchRadius)
+
+			assert.Equal(t, tt.request.RestaurantID, response.RestaurantID)
+			assert.Equal(t, tt.deliverable, response.Deliverable)
+			assert.Equal(t, tt.reason, response.Reason)
+			assert.Equal(t, tt.distanceKm, response.DistanceKm)
+		})
+	}
+}
+
+func distance(km float64) *float64 {
+	return &km
+}
@@ -0,0 +1,76 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/sebastianreh/distance-calculator-api/pkg/geozone"
+)
+
+const featureCollectionType = "FeatureCollection"
+
+// DeliveryZones holds the delivery zone of each restaurant by id
+type DeliveryZones map[string]*geozone.Zone
+
+type (
+	featureCollection struct {
+		Type     string    `json:"type"`
+		Features []feature `json:"features"`
+	}
+
+	feature struct {
+		Properties struct {
+			RestaurantID string `json:"restaurant_id"`
+		} `json:"properties"`
+		Geometry geozone.Zone `json:"geometry"`
+	}
+)
+
+// ReadDeliveryZones decodes a GeoJSON FeatureCollection with one Polygon or MultiPolygon feature per restaurant,
+// identified by its restaurant_id property
+func ReadDeliveryZones(reader io.Reader) (DeliveryZones, error) {
+	var collection featureCollection
+	if err := json.NewDecoder(reader).Decode(&collection); err != nil {
+		return nil, fmt.Errorf("invalid delivery zones, %w", err)
+	}
+	if collection.Type != featureCollectionType {
+		return nil, fmt.Errorf("invalid delivery zones, expected a %s", featureCollectionType)
+	}
+
+	zones := make(DeliveryZones, len(collection.Features))
+	for i, zoneFeature := range collection.Features {
+		restaurantID := zoneFeature.Properties.RestaurantID
+		if restaurantID == "" {
+			return nil, fmt.Errorf("invalid delivery zones, feature %d has no restaurant_id", i)
+		}
+		if _, duplicated := zones[restaurantID]; duplicated {
+			return nil, fmt.Errorf("invalid delivery zones, restaurant %s has more than one feature", restaurantID)
+		}
+
+		zone := zoneFeature.Geometry
+		if len(zone.Polygons) == 0 {
+			return nil, fmt.Errorf("invalid delivery zones, feature %d has no geometry", i)
+		}
+		zones[restaurantID] = &zone
+	}
+
+	return zones, nil
+}
+
+// ZoneRadius is the radius around the restaurant covering its whole zone, zero without one
+func (r Restaurant) ZoneRadius() float64 {
+	if r.Zone == nil {
+		return 0
+	}
+	return r.Zone.BoundingRadius(r.Lat, r.Long)
+}
+
+// deliversTo tells if a point at distance km of the restaurant is within its zone when it has one, or within
+// radius otherwise
+func deliversTo(zone *geozone.Zone, radius, lat, long, distance float64) bool {
+	if zone != nil {
+		return zone.Contains(lat, long)
+	}
+	return distance <= radius
+}
@@ -0,0 +1,109 @@
+package entities_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// a zone running east of restaurant 1 only, along the river, about 8 km long and 1 km wide
+const deliveryZones = `{"type": "FeatureCollection", "features": [
+	{"type": "Feature", "properties": {"restaurant_id": "1"}, "geometry": {"type": "Polygon", "coordinates": [
+		[[-0.105, 51.495], [0.01, 51.495], [0.01, 51.505], [-0.105, 51.505], [-0.105, 51.495]]
+	]}}
+]}`
+
+func Test_ReadDeliveryZones(t *testing.T) {
+	t.Run("zones by restaurant", func(t *testing.T) {
+		zones, err := entities.ReadDeliveryZones(strings.NewReader(deliveryZones))
+
+		assert.NoError(t, err)
+		require.Contains(t, zones, "1")
+		assert.True(t, zones["1"].Contains(51.5, 0))
+	})
+
+	tests := []struct {
+		name  string
+		zones string
+	}{
+		{"not json", "restaurant 1 delivers east"},
+		{"not a feature collection", `{"type": "Polygon", "coordinates": []}`},
+		{"missing restaurant id", `{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {},
+			"geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}}]}`},
+		{"missing geometry", `{"type": "FeatureCollection", "features": [{"type": "Feature",
+			"properties": {"restaurant_id": "1"}}]}`},
+		{"unsupported geometry", `{"type": "FeatureCollection", "features": [{"type": "Feature",
+			"properties": {"restaurant_id": "1"}, "geometry": {"type": "Point", "coordinates": [0, 0]}}]}`},
+		{"duplicated restaurant", `{"type": "FeatureCollection", "features": [
+			{"type": "Feature", "properties": {"restaurant_id": "1"},
+				"geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}},
+			{"type": "Feature", "properties": {"restaurant_id": "1"},
+				"geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}}]}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := entities.ReadDeliveryZones(strings.NewReader(test.zones))
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_CalculationRequest_FindRestaurantsInRadius_DeliveryZones(t *testing.T) {
+	zones, err := entities.ReadDeliveryZones(strings.NewReader(deliveryZones))
+	require.NoError(t, err)
+	restaurants := entities.Restaurants{
+		{ID: "1", Lat: 51.5, Long: -0.1, Radius: 2, Schedule: everyDay("24h"), Zone: zones["1"]},
+		{ID: "2", Lat: 51.5, Long: -0.1, Radius: 2, Schedule: everyDay("24h")},
+	}
+	timeRadiusMap := restaurants.CreateTimeRadiusMap()
+	candidates := []entities.RestaurantIDLatLng{
+		{ID: "1", Lat: 51.5, Long: -0.1},
+		{ID: "2", Lat: 51.5, Long: -0.1},
+	}
+	now := time.Date(2023, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		lat      float64
+		long     float64
+		expected []string
+	}{
+		{"inside both", 51.5, -0.09, []string{"1", "2"}},
+		{"zone beyond the radius", 51.5, 0, []string{"1"}},
+		{"radius outside the zone", 51.49, -0.1, []string{"2"}},
+		{"outside both", 51.45, 0, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := entities.CalculationRequest{Now: now, Lat: test.lat, Long: test.long}
+
+			response := request.NewCalculationResponse(request.FindRestaurantsInRadius(timeRadiusMap, nil, candidates))
+
+			assert.Equal(t, test.expected, response.RestaurantIDs)
+		})
+	}
+
+	t.Run("explain and deliverability agree", func(t *testing.T) {
+		explanation := entities.CalculationRequest{Now: now, Lat: 51.49, Long: -0.1}.Explain(timeRadiusMap, nil,
+			candidates, 10)
+		deliverability := entities.DeliverabilityRequest{RestaurantID: "1", Now: now, Lat: 51.5, Long: 0}.
+			CheckDeliverability(timeRadiusMap, nil, restaurants[0].MaxRadius())
+
+		assert.Equal(t, entities.ReasonOutOfDeliveryRadius, explanation.Candidates[0].EliminatedBy)
+		assert.Empty(t, explanation.Candidates[1].EliminatedBy)
+		assert.True(t, deliverability.Deliverable)
+	})
+
+	t.Run("zone radius covers the zone", func(t *testing.T) {
+		assert.Greater(t, restaurants[0].MaxRadius(), 7.0)
+		assert.Equal(t, restaurants[0].MaxRadius(), restaurants[0].ZoneRadius())
+		assert.Zero(t, restaurants[1].ZoneRadius())
+	})
+}
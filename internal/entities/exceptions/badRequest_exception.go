@@ -0,0 +1,22 @@
+package exceptions
+
+type BadRequestException interface {
+	Error() string
+	IsBadRequestError() bool
+}
+
+type badRequestException struct {
+	ErrMessage string
+}
+
+func (exception *badRequestException) Error() string {
+	return exception.ErrMessage
+}
+
+func (exception *badRequestException) IsBadRequestError() bool {
+	return true
+}
+
+func NewBadRequestException(message string) BadRequestException {
+	return &badRequestException{ErrMessage: message}
+}
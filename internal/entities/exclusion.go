@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/pkg/geozone"
+)
+
+// Exclusion blocks deliveries into its zone, e.g. an airport or a flooded district, whatever restaurant radius
+// reaches it. From and To, both optional, limit it to a time window, From included and To excluded.
+type Exclusion struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	Zone      geozone.Zone `json:"zone"`
+	From      *time.Time   `json:"from,omitempty"`
+	To        *time.Time   `json:"to,omitempty"`
+	Reason    string       `json:"reason,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// Exclusions is the set of exclusion zones every customer point is checked against
+type Exclusions []Exclusion
+
+// NewExclusion validates an exclusion to be created
+func NewExclusion(exclusion Exclusion, now time.Time) (Exclusion, error) {
+	if strings.TrimSpace(exclusion.Name) == "" {
+		return exclusion, fmt.Errorf("name is required")
+	}
+	if len(exclusion.Zone.Polygons) == 0 {
+		return exclusion, fmt.Errorf("zone is required, expected a GeoJSON Polygon or MultiPolygon geometry")
+	}
+	if exclusion.From != nil && exclusion.To != nil && !exclusion.To.After(*exclusion.From) {
+		return exclusion, fmt.Errorf("to must be after from")
+	}
+
+	exclusion.ID = strconv.FormatInt(now.UnixNano(), 10)
+	exclusion.CreatedAt = now.UTC()
+
+	return exclusion, nil
+}
+
+// ActiveAt tells if now falls within the exclusion's time window, always true without one
+func (e Exclusion) ActiveAt(now time.Time) bool {
+	return (e.From == nil || !now.Before(*e.From)) && (e.To == nil || now.Before(*e.To))
+}
+
+// Excluding returns the first exclusion active at now with the point inside its zone
+func (e Exclusions) Excluding(lat, long float64, now time.Time) (Exclusion, bool) {
+	for _, exclusion := range e {
+		if exclusion.ActiveAt(now) && exclusion.Zone.Contains(lat, long) {
+			return exclusion, true
+		}
+	}
+	return Exclusion{}, false
+}
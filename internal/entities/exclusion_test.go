@@ -0,0 +1,83 @@
+package entities_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// an airport west of London, and a district flooded for the night
+const exclusions = `[
+	{"id": "1", "name": "airport", "zone": {"type": "Polygon", "coordinates": [
+		[[-0.49, 51.46], [-0.42, 51.46], [-0.42, 51.48], [-0.49, 51.48], [-0.49, 51.46]]]}},
+	{"id": "2", "name": "flood", "from": "2023-12-01T20:00:00Z", "to": "2023-12-02T08:00:00Z",
+		"zone": {"type": "MultiPolygon", "coordinates": [
+			[[[-0.12, 51.49], [-0.08, 51.49], [-0.08, 51.51], [-0.12, 51.51], [-0.12, 51.49]]]]}}
+]`
+
+func Test_NewExclusion(t *testing.T) {
+	var decoded entities.Exclusions
+	require.NoError(t, json.Unmarshal([]byte(exclusions), &decoded))
+	now := time.Date(2023, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("valid exclusion", func(t *testing.T) {
+		exclusion, err := entities.NewExclusion(entities.Exclusion{Name: "airport", Zone: decoded[0].Zone}, now)
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, exclusion.ID)
+		assert.Equal(t, now, exclusion.CreatedAt)
+	})
+
+	from := time.Date(2023, 12, 2, 8, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 12, 1, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		exclusion entities.Exclusion
+	}{
+		{"missing name", entities.Exclusion{Zone: decoded[0].Zone}},
+		{"missing zone", entities.Exclusion{Name: "airport"}},
+		{"to before from", entities.Exclusion{Name: "flood", Zone: decoded[1].Zone, From: &from, To: &to}},
+		{"empty window", entities.Exclusion{Name: "flood", Zone: decoded[1].Zone, From: &from, To: &from}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := entities.NewExclusion(test.exclusion, now)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_Exclusions_Excluding(t *testing.T) {
+	var decoded entities.Exclusions
+	require.NoError(t, json.Unmarshal([]byte(exclusions), &decoded))
+
+	tests := []struct {
+		name     string
+		lat      float64
+		long     float64
+		now      time.Time
+		expected string
+	}{
+		{"inside an exclusion without window", 51.47, -0.45, time.Date(2023, 12, 1, 12, 0, 0, 0, time.UTC), "1"},
+		{"inside a window", 51.5, -0.1, time.Date(2023, 12, 1, 23, 0, 0, 0, time.UTC), "2"},
+		{"window start included", 51.5, -0.1, time.Date(2023, 12, 1, 20, 0, 0, 0, time.UTC), "2"},
+		{"window end excluded", 51.5, -0.1, time.Date(2023, 12, 2, 8, 0, 0, 0, time.UTC), ""},
+		{"before the window", 51.5, -0.1, time.Date(2023, 12, 1, 12, 0, 0, 0, time.UTC), ""},
+		{"outside every zone", 51.55, -0.1, time.Date(2023, 12, 1, 23, 0, 0, 0, time.UTC), ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exclusion, excluded := decoded.Excluding(test.lat, test.long, test.now)
+
+			assert.Equal(t, test.expected != "", excluded)
+			assert.Equal(t, test.expected, exclusion.ID)
+		})
+	}
+}
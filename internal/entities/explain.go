@@ -0,0 +1,104 @@
+package entities
+
+import (
+	"math"
+	"time"
+
+	mathFormulas "github.com/sebastianreh/distance-calculator-api/pkg/math_formulas"
+	"github.com/sebastianreh/distance-calculator-api/pkg/schedule"
+)
+
+const (
+	DebugExplain = "explain"
+	// ReasonFilteredOut eliminates candidates not matching the rating or attribute filters
+	ReasonFilteredOut = "filtered_out"
+)
+
+// Explanation shows how FindRestaurantsInRadius got to its results, for every candidate of the geo search
+type Explanation struct {
+	EvaluatedAt    time.Time              `json:"evaluated_at"`
+	SearchRadiusKm float64                `json:"search_radius_km"`
+	Candidates     []CandidateExplanation `json:"candidates"`
+}
+
+// CandidateExplanation holds the numbers a candidate was evaluated with and the stage that eliminated it, empty
+// when the restaurant is in the results
+type CandidateExplanation struct {
+	ID               string  `json:"id"`
+	Included         bool    `json:"included"`
+	EliminatedBy     string  `json:"eliminated_by,omitempty"`
+	DistanceKm       float64 `json:"distance_km"`
+	DeliveryRadiusKm float64 `json:"delivery_radius_km"`
+	Rating           float64 `json:"rating"`
+	TimeZone         string  `json:"time_zone,omitempty"`
+	LocalTime        string  `json:"local_time,omitempty"`
+	Schedule         string  `json:"schedule,omitempty"`
+	OpensAt          string  `json:"opens_at,omitempty"`
+	ClosesAt         string  `json:"closes_at,omitempty"`
+}
+
+func (request CalculationRequest) Explains() bool {
+	return request.Debug == DebugExplain
+}
+
+// Explain evaluates every candidate through the same stages as FindRestaurantsInRadius, in the same order,
+// recording the first one eliminating it
+func (request CalculationRequest) Explain(timeRadiusMap TimeRadiusMap, overrides Overrides,
+	restaurantInUserRadius []RestaurantIDLatLng, searchRadius float64) *Explanation {
+	explanation := &Explanation{
+		EvaluatedAt:    request.Now,
+		SearchRadiusKm: searchRadius,
+		Candidates:     make([]CandidateExplanation, 0, len(restaurantInUserRadius)),
+	}
+
+	for _, restaurant := range restaurantInUserRadius {
+		explanation.Candidates = append(explanation.Candidates, request.explainCandidate(timeRadiusMap, overrides,
+			restaurant))
+	}
+
+	return explanation
+}
+
+func (request CalculationRequest) explainCandidate(timeRadiusMap TimeRadiusMap, overrides Overrides,
+	restaurant RestaurantIDLatLng) CandidateExplanation {
+	distance := mathFormulas.Haversine(request.Lat, request.Long, restaurant.Lat, restaurant.Long)
+	candidate := CandidateExplanation{
+		ID:         restaurant.ID,
+		DistanceKm: math.Round(distance*distancePrecision) / distancePrecision,
+	}
+
+	timeRadius, ok := timeRadiusMap[restaurant.ID]
+	if !ok {
+		candidate.EliminatedBy = ReasonUnknownRestaurant
+		return candidate
+	}
+
+	localTime := schedule.LocalTime(request.Now, timeRadius.TimeZone)
+	candidate.DeliveryRadiusKm = timeRadius.radiusAt(request.Now)
+	candidate.Rating = timeRadius.Rating
+	candidate.TimeZone = timeRadius.TimeZone
+	candidate.LocalTime = localTime.Format(time.RFC3339)
+	week := timeRadius.week(localTime, overrides[restaurant.ID])
+	candidate.Schedule = week[localTime.Weekday()].String()
+
+	if (request.MinRating > 0 || len(request.AttributeFilters) > 0) && !request.matchesFilters(timeRadius) {
+		candidate.EliminatedBy = ReasonFilteredOut
+		return candidate
+	}
+
+	interval, _, open := week.OpenInterval(localTime)
+	if !open {
+		candidate.EliminatedBy = ReasonClosed
+		return candidate
+	}
+	candidate.OpensAt = interval.Open.String()
+	candidate.ClosesAt = interval.Close.String()
+
+	if !deliversTo(timeRadius.Zone, candidate.DeliveryRadiusKm, request.Lat, request.Long, distance) {
+		candidate.EliminatedBy = ReasonOutOfDeliveryRadius
+		return candidate
+	}
+
+	candidate.Included = true
+	return candidate
+}
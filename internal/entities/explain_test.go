@@ -0,0 +1,48 @@
+package entities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CalculationRequest_Explain(t *testing.T) {
+	dinner := everyDay("19:00-23:30")
+	lunch := everyDay("11:30-15:00")
+	timeRadiusMap := entities.Restaurants{
+		{ID: "included", Lat: 51.5074, Long: -0.1278, Radius: 5, Rating: 4.5, Schedule: dinner},
+		{ID: "low-rating", Lat: 51.5074, Long: -0.1278, Radius: 5, Rating: 3, Schedule: dinner},
+		{ID: "closed", Lat: 51.5074, Long: -0.1278, Radius: 5, Rating: 4.5, Schedule: lunch, TimeZone: "Europe/London"},
+		{ID: "far", Lat: 51.5574, Long: -0.1278, Radius: 2, Rating: 4.5, Schedule: dinner},
+	}.CreateTimeRadiusMap()
+	candidates := []entities.RestaurantIDLatLng{
+		{ID: "included", Lat: 51.5074, Long: -0.1278},
+		{ID: "low-rating", Lat: 51.5074, Long: -0.1278},
+		{ID: "closed", Lat: 51.5074, Long: -0.1278},
+		{ID: "far", Lat: 51.5574, Long: -0.1278},
+		{ID: "ghost", Lat: 51.5074, Long: -0.1278},
+	}
+	request := entities.CalculationRequest{Lat: 51.5174, Long: -0.1278, MinRating: 4, Debug: entities.DebugExplain,
+		Now: time.Date(2023, 10, 17, 20, 0, 0, 0, time.UTC)}
+
+	explanation := request.Explain(timeRadiusMap, nil, candidates, 6)
+
+	assert.Equal(t, request.Now, explanation.EvaluatedAt)
+	assert.Equal(t, float64(6), explanation.SearchRadiusKm)
+	assert.Equal(t, []entities.CandidateExplanation{
+		{ID: "included", Included: true, DistanceKm: 1.112, DeliveryRadiusKm: 5, Rating: 4.5,
+			LocalTime: "2023-10-17T20:00:00Z", Schedule: "19:00-23:30", OpensAt: "19:00", ClosesAt: "23:30"},
+		{ID: "low-rating", EliminatedBy: entities.ReasonFilteredOut, DistanceKm: 1.112, DeliveryRadiusKm: 5, Rating: 3,
+			LocalTime: "2023-10-17T20:00:00Z", Schedule: "19:00-23:30"},
+		{ID: "closed", EliminatedBy: entities.ReasonClosed, DistanceKm: 1.112, DeliveryRadiusKm: 5, Rating: 4.5,
+			TimeZone: "Europe/London", LocalTime: "2023-10-17T21:00:00+01:00", Schedule: "11:30-15:00"},
+		{ID: "far", EliminatedBy: entities.ReasonOutOfDeliveryRadius, DistanceKm: 4.448, DeliveryRadiusKm: 2,
+			Rating: 4.5, LocalTime: "2023-10-17T20:00:00Z", Schedule: "19:00-23:30", OpensAt: "19:00", ClosesAt: "23:30"},
+		{ID: "ghost", EliminatedBy: entities.ReasonUnknownRestaurant, DistanceKm: 1.112},
+	}, explanation.Candidates)
+
+	response := request.NewCalculationResponse(request.FindRestaurantsInRadius(timeRadiusMap, nil, candidates))
+	assert.Equal(t, []string{"included"}, response.RestaurantIDs)
+}
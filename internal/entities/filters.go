@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+)
+
+const attributeSeparator = ":"
+
+// AttributeFilters maps a restaurant metadata attribute to its accepted values. A restaurant matches when,
+// for every attribute, its value is any of the accepted ones.
+type AttributeFilters map[string][]string
+
+// ParseAttributeFilters parses attribute:value filters, e.g. cuisine:italian. Names and values are case insensitive.
+func ParseAttributeFilters(rawFilters []string) (AttributeFilters, error) {
+	if len(rawFilters) == 0 {
+		return nil, nil
+	}
+
+	filters := make(AttributeFilters, len(rawFilters))
+	for _, rawFilter := range rawFilters {
+		parts := strings.SplitN(rawFilter, attributeSeparator, 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("invalid attribute filter %q, expected attribute:value", rawFilter)
+		}
+		attribute := normalizeColumnName(parts[0])
+		filters[attribute] = append(filters[attribute], strings.TrimSpace(parts[1]))
+	}
+
+	return filters, nil
+}
+
+func (f AttributeFilters) match(metadata map[string]string) bool {
+	for attribute, acceptedValues := range f {
+		value, ok := metadata[attribute]
+		if !ok || !containsFold(acceptedValues, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesFilters tells if a restaurant passes the rating and attribute filters of the request
+func (request CalculationRequest) matchesFilters(timeRadius timeRadiusSchedule) bool {
+	return timeRadius.Rating >= request.MinRating && request.AttributeFilters.match(timeRadius.Metadata)
+}
@@ -0,0 +1,126 @@
+package entities
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/pkg/schedule"
+)
+
+const (
+	dateLayout  = "2006-01-02"
+	daysInWeek  = 7
+	closedHours = "closed"
+)
+
+// Override replaces the regular schedule of a restaurant from the From to the To date, both included and in
+// the restaurant's local time, with Hours in the notation of the hours column. Closures have closed hours.
+type Override struct {
+	ID           string    `json:"id"`
+	RestaurantID string    `json:"restaurant_id"`
+	From         string    `json:"from"`
+	To           string    `json:"to"`
+	Hours        string    `json:"hours"`
+	Reason       string    `json:"reason,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// dayOverride is an override ready to be applied to the days of a schedule
+type dayOverride struct {
+	from string
+	to   string
+	day  schedule.Day
+}
+
+// Overrides holds the overrides of each restaurant by id, the latest created first
+type Overrides map[string][]dayOverride
+
+// NewOverride validates an override to be created, a closure when it has no hours
+func NewOverride(override Override, now time.Time) (Override, error) {
+	if strings.TrimSpace(override.RestaurantID) == "" {
+		return override, fmt.Errorf("restaurant_id is required")
+	}
+
+	from, err := time.Parse(dateLayout, override.From)
+	if err != nil {
+		return override, fmt.Errorf("invalid from, expected a YYYY-MM-DD date")
+	}
+	to, err := time.Parse(dateLayout, override.To)
+	if err != nil {
+		return override, fmt.Errorf("invalid to, expected a YYYY-MM-DD date")
+	}
+	if to.Before(from) {
+		return override, fmt.Errorf("to must not be before from")
+	}
+
+	if strings.TrimSpace(override.Hours) == "" {
+		override.Hours = closedHours
+	}
+	day, err := schedule.ParseDay(override.Hours)
+	if err != nil {
+		return override, fmt.Errorf("invalid hours, %w", err)
+	}
+
+	override.ID = strconv.FormatInt(now.UnixNano(), 10)
+	override.Hours = day.String()
+	override.CreatedAt = now.UTC()
+
+	return override, nil
+}
+
+// NewOverrides groups overrides by restaurant, leaving out the ones with hours that cannot be parsed
+func NewOverrides(overrides []Override) Overrides {
+	sorted := append([]Override(nil), overrides...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+
+	restaurantOverrides := make(Overrides)
+	for _, override := range sorted {
+		day, err := schedule.ParseDay(override.Hours)
+		if err != nil {
+			continue
+		}
+		restaurantOverrides[override.RestaurantID] = append(restaurantOverrides[override.RestaurantID],
+			dayOverride{from: override.From, to: override.To, day: day})
+	}
+
+	return restaurantOverrides
+}
+
+// week is the schedule in effect around the local time: the regular schedule with the restaurant's overrides of
+// the days from the day before to five days after applied, every day a schedule evaluation can reach but the
+// last one
+func (s timeRadiusSchedule) week(localTime time.Time, overrides []dayOverride) schedule.Week {
+	week := s.Schedule
+	if len(overrides) == 0 {
+		return week
+	}
+
+	for offset := -1; offset < daysInWeek-1; offset++ {
+		date := localTime.AddDate(0, 0, offset)
+		if day, ok := overrideOn(overrides, date.Format(dateLayout)); ok {
+			week[date.Weekday()] = day
+		}
+	}
+	return week
+}
+
+func overrideOn(overrides []dayOverride, date string) (schedule.Day, bool) {
+	for _, override := range overrides {
+		// dates in the YYYY-MM-DD layout sort as strings
+		if override.from <= date && date <= override.to {
+			return override.day, true
+		}
+	}
+	return schedule.Day{}, false
+}
+
+// openInterval evaluates the schedule in effect at now with the restaurant's overrides, in its local time
+func (s timeRadiusSchedule) openInterval(now time.Time, overrides []dayOverride) (schedule.Interval, int, bool) {
+	localTime := schedule.LocalTime(now, s.TimeZone)
+	return s.week(localTime, overrides).OpenInterval(localTime)
+}
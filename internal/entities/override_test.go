@@ -0,0 +1,115 @@
+package entities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewOverride(t *testing.T) {
+	now := time.Date(2023, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("closure without hours", func(t *testing.T) {
+		override, err := entities.NewOverride(entities.Override{RestaurantID: "1", From: "2023-12-25",
+			To: "2023-12-26", Reason: "christmas"}, now)
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, override.ID)
+		assert.Equal(t, "closed", override.Hours)
+		assert.Equal(t, now, override.CreatedAt)
+	})
+
+	t.Run("special hours normalized", func(t *testing.T) {
+		override, err := entities.NewOverride(entities.Override{RestaurantID: "1", From: "2023-12-31",
+			To: "2023-12-31", Hours: "18:00 - 03:00"}, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "18:00-03:00", override.Hours)
+	})
+
+	tests := []struct {
+		name     string
+		override entities.Override
+	}{
+		{"missing restaurant", entities.Override{From: "2023-12-25", To: "2023-12-25"}},
+		{"bad from", entities.Override{RestaurantID: "1", From: "25/12/2023", To: "2023-12-25"}},
+		{"bad to", entities.Override{RestaurantID: "1", From: "2023-12-25", To: "tomorrow"}},
+		{"to before from", entities.Override{RestaurantID: "1", From: "2023-12-26", To: "2023-12-25"}},
+		{"bad hours", entities.Override{RestaurantID: "1", From: "2023-12-25", To: "2023-12-25", Hours: "late"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := entities.NewOverride(test.override, now)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_CalculationRequest_FindRestaurantsInRadius_Overrides(t *testing.T) {
+	timeRadiusMap := entities.Restaurants{
+		{ID: "1", Lat: 51.5, Long: -0.1, Radius: 5, Schedule: everyDay("11:00-23:00")},
+		{ID: "2", Lat: 51.5, Long: -0.1, Radius: 5, Schedule: everyDay("11:00-23:00"), TimeZone: "America/New_York"},
+	}.CreateTimeRadiusMap()
+	candidates := []entities.RestaurantIDLatLng{
+		{ID: "1", Lat: 51.5, Long: -0.1},
+		{ID: "2", Lat: 51.5, Long: -0.1},
+	}
+	created := time.Date(2023, 12, 1, 10, 0, 0, 0, time.UTC)
+	overrides := entities.NewOverrides([]entities.Override{
+		{RestaurantID: "1", From: "2023-12-24", To: "2023-12-25", Hours: "closed", CreatedAt: created},
+		{RestaurantID: "1", From: "2023-12-31", To: "2023-12-31", Hours: "20:00-03:00", CreatedAt: created},
+		{RestaurantID: "1", From: "2023-12-25", To: "2023-12-25", Hours: "12:00-15:00", CreatedAt: created.Add(time.Hour)},
+		{RestaurantID: "2", From: "2023-12-25", To: "2023-12-25", Hours: "closed", CreatedAt: created},
+	})
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected []string
+	}{
+		{"regular hours", time.Date(2023, 12, 20, 20, 0, 0, 0, time.UTC), []string{"1", "2"}},
+		{"closure", time.Date(2023, 12, 24, 20, 0, 0, 0, time.UTC), []string{"2"}},
+		{"latest override wins", time.Date(2023, 12, 25, 13, 0, 0, 0, time.UTC), []string{"1"}},
+		{"outside special hours", time.Date(2023, 12, 25, 16, 0, 0, 0, time.UTC), []string{}},
+		{"dates in local time", time.Date(2023, 12, 25, 3, 0, 0, 0, time.UTC), []string{"2"}},
+		{"closure in local time", time.Date(2023, 12, 26, 2, 0, 0, 0, time.UTC), []string{}},
+		{"special hours past midnight", time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC), []string{"1", "2"}},
+		{"regular hours after special ones", time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC), []string{"2"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := entities.CalculationRequest{Now: test.now, Lat: 51.5, Long: -0.1}
+
+			response := request.NewCalculationResponse(request.FindRestaurantsInRadius(timeRadiusMap, overrides,
+				candidates))
+
+			assert.Equal(t, test.expected, response.RestaurantIDs)
+		})
+	}
+
+	t.Run("explain and deliverability apply them", func(t *testing.T) {
+		now := time.Date(2023, 12, 24, 20, 0, 0, 0, time.UTC)
+		explanation := entities.CalculationRequest{Now: now, Lat: 51.5, Long: -0.1}.Explain(timeRadiusMap, overrides,
+			candidates, 10)
+		deliverability := entities.DeliverabilityRequest{RestaurantID: "1", Now: now, Lat: 51.5, Long: -0.1}.
+			CheckDeliverability(timeRadiusMap, overrides, 10)
+
+		assert.Equal(t, entities.ReasonClosed, explanation.Candidates[0].EliminatedBy)
+		assert.Equal(t, "closed", explanation.Candidates[0].Schedule)
+		assert.Empty(t, explanation.Candidates[1].EliminatedBy)
+		assert.Equal(t, entities.ReasonClosed, deliverability.Reason)
+	})
+
+	t.Run("regular schedule without overrides", func(t *testing.T) {
+		request := entities.CalculationRequest{Now: time.Date(2023, 12, 24, 20, 0, 0, 0, time.UTC), Lat: 51.5,
+			Long: -0.1}
+
+		response := request.NewCalculationResponse(request.FindRestaurantsInRadius(timeRadiusMap, nil, candidates))
+
+		assert.Equal(t, []string{"1", "2"}, response.RestaurantIDs)
+	})
+}
@@ -0,0 +1,165 @@
+package entities
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	SortDistance    = "distance"
+	SortRating      = "rating"
+	SortClosingSoon = "closing_soon"
+)
+
+// pageCursor points right after the last restaurant of a page. Pages are keyset based, so the next page is
+// stable as long as the dataset version does not change. Later pages are evaluated at the time of the first one,
+// keys such as the minutes until closing would move otherwise, and only for the query the cursor was created for.
+type pageCursor struct {
+	Version string    `json:"v"`
+	Sort    string    `json:"s"`
+	Key     float64   `json:"k"`
+	ID      string    `json:"id"`
+	Now     time.Time `json:"t"`
+	Query   uint64    `json:"q"`
+}
+
+func IsValidSort(sortBy string) bool {
+	return sortBy == SortDistance || sortBy == SortRating || sortBy == SortClosingSoon
+}
+
+// Paginates tells if the request asks for sorted or paginated results
+func (request CalculationRequest) Paginates() bool {
+	return request.Sort != "" || request.Limit > 0 || request.Cursor != ""
+}
+
+// Paginate sorts the restaurants and returns the page requested, with the cursor of the next one when there
+// are more results. Sorting defaults to distance when only a limit or a cursor are given.
+func (request CalculationRequest) Paginate(restaurants []RestaurantDetails, version string) ([]RestaurantDetails,
+	string, error) {
+	if !request.Paginates() {
+		return restaurants, "", nil
+	}
+
+	sortBy := request.Sort
+	var after *pageCursor
+	if request.Cursor != "" {
+		cursor, err := request.decodeCursor()
+		if err != nil {
+			return nil, "", err
+		}
+		if cursor.Version != version {
+			return nil, "", errors.New("cursor belongs to a previous dataset, request the first page again")
+		}
+		if sortBy != "" && sortBy != cursor.Sort {
+			return nil, "", fmt.Errorf("cursor was created for sort %s", cursor.Sort)
+		}
+		sortBy = cursor.Sort
+		after = &cursor
+	}
+	if sortBy == "" {
+		sortBy = SortDistance
+	}
+
+	sorted := append(make([]RestaurantDetails, 0, len(restaurants)), restaurants...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return isBefore(sortKey(sorted[i], sortBy), sorted[i].ID, sortKey(sorted[j], sortBy), sorted[j].ID)
+	})
+
+	start := 0
+	if after != nil {
+		start = sort.Search(len(sorted), func(i int) bool {
+			return isBefore(after.Key, after.ID, sortKey(sorted[i], sortBy), sorted[i].ID)
+		})
+	}
+
+	page := sorted[start:]
+	if request.Limit <= 0 || len(page) <= request.Limit {
+		return page, "", nil
+	}
+
+	page = page[:request.Limit]
+	last := page[len(page)-1]
+	nextCursor := encodeCursor(pageCursor{Version: version, Sort: sortBy, Key: sortKey(last, sortBy), ID: last.ID,
+		Now: request.Now, Query: request.queryHash()})
+
+	return page, nextCursor, nil
+}
+
+// sortKey returns the ascending key of a restaurant for the given sort, ties are broken by id
+func sortKey(restaurant RestaurantDetails, sortBy string) float64 {
+	switch sortBy {
+	case SortRating:
+		return -restaurant.Rating
+	case SortClosingSoon:
+		return float64(restaurant.MinutesUntilClosing)
+	default:
+		return restaurant.DistanceKm
+	}
+}
+
+func isBefore(key float64, id string, otherKey float64, otherID string) bool {
+	if key != otherKey {
+		return key < otherKey
+	}
+	return id < otherID
+}
+
+func encodeCursor(cursor pageCursor) string {
+	cursorBytes, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(cursorBytes)
+}
+
+// ResumeCursor returns the request evaluated at the time of the page its cursor continues, the request itself
+// when it has no cursor
+func (request CalculationRequest) ResumeCursor() (CalculationRequest, error) {
+	if request.Cursor == "" {
+		return request, nil
+	}
+
+	cursor, err := request.decodeCursor()
+	if err != nil {
+		return request, err
+	}
+	request.Now = cursor.Now
+	return request, nil
+}
+
+// decodeCursor decodes the request cursor, rejecting the ones created for another location or filters
+func (request CalculationRequest) decodeCursor() (pageCursor, error) {
+	var cursor pageCursor
+	cursorBytes, err := base64.RawURLEncoding.DecodeString(request.Cursor)
+	if err != nil || json.Unmarshal(cursorBytes, &cursor) != nil || !IsValidSort(cursor.Sort) {
+		return cursor, errors.New("invalid cursor")
+	}
+	if cursor.Query != request.queryHash() {
+		return cursor, errors.New("cursor was created for another location or filters")
+	}
+	return cursor, nil
+}
+
+// queryHash identifies the location and filters of the request, attribute filters in a stable order
+func (request CalculationRequest) queryHash() uint64 {
+	hash := fnv.New64a()
+	for _, value := range []float64{request.Lat, request.Long, request.MinRating} {
+		_, _ = hash.Write([]byte(strconv.FormatFloat(value, 'g', -1, bitSize) + "|"))
+	}
+
+	attributes := make([]string, 0, len(request.AttributeFilters))
+	for attribute := range request.AttributeFilters {
+		attributes = append(attributes, attribute)
+	}
+	sort.Strings(attributes)
+	for _, attribute := range attributes {
+		values := append([]string(nil), request.AttributeFilters[attribute]...)
+		sort.Strings(values)
+		_, _ = hash.Write([]byte(fmt.Sprintf("%s=%q|", attribute, values)))
+	}
+
+	return hash.Sum64()
+}
@@ -0,0 +1,146 @@
+package entities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CalculationRequest_Paginate(t *testing.T) {
+	restaurants := []entities.RestaurantDetails{
+		{ID: "a", DistanceKm: 2.5, Rating: 4.1, MinutesUntilClosing: 30},
+		{ID: "b", DistanceKm: 0.4, Rating: 3.2, MinutesUntilClosing: 300},
+		{ID: "c", DistanceKm: 1.2, Rating: 4.8, MinutesUntilClosing: 90},
+		{ID: "d", DistanceKm: 1.2, Rating: 4.1, MinutesUntilClosing: 15},
+		{ID: "e", DistanceKm: 3.9, Rating: 2.0, MinutesUntilClosing: 45},
+	}
+
+	pageIDs := func(page []entities.RestaurantDetails) []string {
+		ids := make([]string, 0, len(page))
+		for _, restaurant := range page {
+			ids = append(ids, restaurant.ID)
+		}
+		return ids
+	}
+
+	t.Run("no pagination keeps the original order", func(t *testing.T) {
+		page, next, err := entities.CalculationRequest{}.Paginate(restaurants, "v1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, restaurants, page)
+		assert.Empty(t, next)
+	})
+
+	t.Run("sorts", func(t *testing.T) {
+		tests := map[string][]string{
+			entities.SortDistance:    {"b", "c", "d", "a", "e"},
+			entities.SortRating:      {"c", "a", "d", "b", "e"},
+			entities.SortClosingSoon: {"d", "a", "e", "c", "b"},
+		}
+		for sortBy, expected := range tests {
+			page, _, err := entities.CalculationRequest{Sort: sortBy}.Paginate(restaurants, "v1")
+
+			assert.NoError(t, err)
+			assert.Equal(t, expected, pageIDs(page), sortBy)
+		}
+	})
+
+	t.Run("walks every page with the cursor", func(t *testing.T) {
+		request := entities.CalculationRequest{Sort: entities.SortRating, Limit: 2}
+		var ids []string
+		for pages := 0; pages < 3; pages++ {
+			page, next, err := request.Paginate(restaurants, "v1")
+			assert.NoError(t, err)
+			ids = append(ids, pageIDs(page)...)
+			if next == "" {
+				break
+			}
+			request.Cursor = next
+		}
+
+		assert.Equal(t, []string{"c", "a", "d", "b", "e"}, ids)
+	})
+
+	t.Run("cursor from another dataset version", func(t *testing.T) {
+		_, next, _ := entities.CalculationRequest{Limit: 2}.Paginate(restaurants, "v1")
+
+		_, _, err := entities.CalculationRequest{Limit: 2, Cursor: next}.Paginate(restaurants, "v2")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("cursor for another sort", func(t *testing.T) {
+		_, next, _ := entities.CalculationRequest{Limit: 2}.Paginate(restaurants, "v1")
+
+		_, _, err := entities.CalculationRequest{Sort: entities.SortRating, Cursor: next}.Paginate(restaurants, "v1")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		_, _, err := entities.CalculationRequest{Cursor: "not-a-cursor"}.Paginate(restaurants, "v1")
+
+		assert.Error(t, err)
+	})
+}
+
+func Test_CalculationRequest_Paginate_LaterPages(t *testing.T) {
+	timeRadiusMap := entities.Restaurants{
+		{ID: "a", Lat: 51.5, Long: -0.1, Radius: 5, Schedule: everyDay("10:00-20:00")},
+		{ID: "b", Lat: 51.5, Long: -0.1, Radius: 5, Schedule: everyDay("10:00-20:01")},
+		{ID: "c", Lat: 51.5, Long: -0.1, Radius: 5, Schedule: everyDay("10:00-20:02")},
+		{ID: "d", Lat: 51.5, Long: -0.1, Radius: 5, Schedule: everyDay("10:00-20:03")},
+	}.CreateTimeRadiusMap()
+	candidates := []entities.RestaurantIDLatLng{
+		{ID: "a", Lat: 51.5, Long: -0.1},
+		{ID: "b", Lat: 51.5, Long: -0.1},
+		{ID: "c", Lat: 51.5, Long: -0.1},
+		{ID: "d", Lat: 51.5, Long: -0.1},
+	}
+	now := time.Date(2023, 10, 18, 19, 0, 0, 0, time.UTC)
+
+	page := func(request entities.CalculationRequest) ([]string, string, error) {
+		request, err := request.ResumeCursor()
+		if err != nil {
+			return nil, "", err
+		}
+		restaurants, next, err := request.Paginate(request.FindRestaurantsInRadius(timeRadiusMap, nil, candidates), "v1")
+		return request.NewCalculationResponse(restaurants).RestaurantIDs, next, err
+	}
+
+	t.Run("closing soon pages evaluated at the first page time", func(t *testing.T) {
+		request := entities.CalculationRequest{Now: now, Lat: 51.5, Long: -0.1, Sort: entities.SortClosingSoon, Limit: 2}
+		first, next, err := page(request)
+		require.NoError(t, err)
+
+		request.Now = now.Add(2 * time.Minute)
+		request.Cursor = next
+		second, _, err := page(request)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, first)
+		assert.Equal(t, []string{"c", "d"}, second)
+	})
+
+	t.Run("cursor for another query", func(t *testing.T) {
+		request := entities.CalculationRequest{Now: now, Lat: 51.5, Long: -0.1, Limit: 2}
+		_, next, err := page(request)
+		require.NoError(t, err)
+
+		for name, other := range map[string]entities.CalculationRequest{
+			"location": {Lat: 51.6, Long: -0.1},
+			"rating":   {Lat: 51.5, Long: -0.1, MinRating: 4},
+			"attributes": {Lat: 51.5, Long: -0.1,
+				AttributeFilters: entities.AttributeFilters{"cuisine": {"italian"}}},
+		} {
+			other.Limit, other.Cursor = 2, next
+
+			_, _, err = page(other)
+
+			assert.Error(t, err, name)
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package entities
+
+// PreprocessReport summarizes a preprocess run so data providers know which rows were dropped and why
+type PreprocessReport struct {
+	DatasetVersion      string         `json:"dataset_version,omitempty"`
+	DryRun              bool           `json:"dry_run"`
+	Published           bool           `json:"published"`
+	RowsRead            int            `json:"rows_read"`
+	RowsAccepted        int            `json:"rows_accepted"`
+	RowsRejected        int            `json:"rows_rejected"`
+	ZonesApplied        int            `json:"zones_applied"`
+	Rejections          []RowRejection `json:"rejections"`
+	RejectionsTruncated bool           `json:"rejections_truncated"`
+}
+
+type RowRejection struct {
+	Line   int    `json:"line"`
+	Column string `json:"column,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func NewPreprocessReport(dryRun bool) PreprocessReport {
+	return PreprocessReport{
+		DryRun:     dryRun,
+		Rejections: make([]RowRejection, 0),
+	}
+}
+
+// Reject counts a rejected row, keeping its details only while the report holds less than maxRejections
+func (r *PreprocessReport) Reject(rowErr *RowError, maxRejections int) {
+	r.RowsRejected++
+	if len(r.Rejections) >= maxRejections {
+		r.RejectionsTruncated = true
+		return
+	}
+
+	r.Rejections = append(r.Rejections, RowRejection{
+		Line:   rowErr.Line,
+		Column: rowErr.Column,
+		Value:  rowErr.Value,
+		Reason: rowErr.Reason,
+	})
+}
@@ -0,0 +1,119 @@
+package entities
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/pkg/schedule"
+)
+
+const (
+	rulesSeparator      = ";"
+	ruleRadiusSeparator = "="
+	weekdayAbbreviation = 3
+)
+
+var errBadRadiusRules = errors.New("bad radius rules format, expected [weekday ]HH:MM-HH:MM=km[;...]")
+
+// RadiusRule replaces the delivery radius of a restaurant within a time window, every day or only when the
+// window starts on the given weekday
+type RadiusRule struct {
+	Weekday *time.Weekday     `json:"weekday,omitempty"`
+	Window  schedule.Interval `json:"window"`
+	Radius  float64           `json:"radius"`
+}
+
+// ParseRadiusRules parses rules such as 17:00-20:00=3;sat 22:00-02:00=8, windows follow the semantics of
+// schedule intervals and weekdays can be abbreviated to their first three letters
+func ParseRadiusRules(value string) ([]RadiusRule, error) {
+	var rules []RadiusRule
+	for _, rawRule := range strings.Split(value, rulesSeparator) {
+		rawWindow, rawRadius, found := strings.Cut(strings.TrimSpace(rawRule), ruleRadiusSeparator)
+		if !found {
+			return nil, errBadRadiusRules
+		}
+
+		var rule RadiusRule
+		if fields := strings.Fields(rawWindow); len(fields) == 2 {
+			weekday, ok := parseWeekday(fields[0])
+			if !ok {
+				return nil, errBadRadiusRules
+			}
+			rule.Weekday = &weekday
+			rawWindow = fields[1]
+		}
+
+		window, err := schedule.ParseDay(rawWindow)
+		if err != nil || len(window.Intervals) != 1 {
+			return nil, errBadRadiusRules
+		}
+		rule.Window = window.Intervals[0]
+
+		rule.Radius, err = strconv.ParseFloat(strings.TrimSpace(rawRadius), bitSize)
+		if err != nil || rule.Radius < 0 {
+			return nil, errBadRadiusRules
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func parseWeekday(value string) (time.Weekday, bool) {
+	value = strings.ToLower(value)
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		name := WeekdayColumn(weekday)
+		if value == name || value == name[:weekdayAbbreviation] {
+			return weekday, true
+		}
+	}
+	return time.Sunday, false
+}
+
+// applies tells if the rule's window includes the local time
+func (r RadiusRule) applies(localTime time.Time) bool {
+	if r.Weekday != nil {
+		return r.Window.Includes(*r.Weekday, localTime)
+	}
+
+	yesterday := (localTime.Weekday() + daysInWeek - 1) % daysInWeek
+	return r.Window.Includes(localTime.Weekday(), localTime) || r.Window.Includes(yesterday, localTime)
+}
+
+// MaxRadius is the largest radius the restaurant can deliver within at any time, the radius covering its zone when
+// it has one
+func (r Restaurant) MaxRadius() float64 {
+	if r.Zone != nil {
+		return r.ZoneRadius()
+	}
+
+	maxRadius := r.Radius
+	for _, rule := range r.RadiusRules {
+		if rule.Radius > maxRadius {
+			maxRadius = rule.Radius
+		}
+	}
+	return maxRadius
+}
+
+// radiusAt is the delivery radius in effect at now: the one covering the zone, the one of the first rule applying
+// in the restaurant's local time, or the regular radius
+func (s timeRadiusSchedule) radiusAt(now time.Time) float64 {
+	if s.Zone != nil {
+		return s.ZoneRadius
+	}
+	if len(s.RadiusRules) == 0 {
+		return s.Radius
+	}
+
+	localTime := schedule.LocalTime(now, s.TimeZone)
+	for _, rule := range s.RadiusRules {
+		if rule.applies(localTime) {
+			return rule.Radius
+		}
+	}
+	return s.Radius
+}
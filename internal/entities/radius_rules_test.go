@@ -0,0 +1,98 @@
+package entities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/sebastianreh/distance-calculator-api/pkg/schedule"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseRadiusRules(t *testing.T) {
+	saturday := time.Saturday
+
+	t.Run("every day and weekday rules", func(t *testing.T) {
+		rules, err := entities.ParseRadiusRules("17:00-20:00=3; Sat 22:00-02:00=8.5")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []entities.RadiusRule{
+			{Window: schedule.Interval{Open: schedule.NewClock(17, 0), Close: schedule.NewClock(20, 0)}, Radius: 3},
+			{Weekday: &saturday, Window: schedule.Interval{Open: schedule.NewClock(22, 0),
+				Close: schedule.NewClock(2, 0)}, Radius: 8.5},
+		}, rules)
+	})
+
+	t.Run("full weekday name", func(t *testing.T) {
+		rules, err := entities.ParseRadiusRules("saturday 24h=2")
+
+		assert.NoError(t, err)
+		require.Len(t, rules, 1)
+		assert.Equal(t, &saturday, rules[0].Weekday)
+		assert.Equal(t, schedule.AllDay, rules[0].Window)
+	})
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"missing radius", "17:00-20:00"},
+		{"bad radius", "17:00-20:00=far"},
+		{"negative radius", "17:00-20:00=-1"},
+		{"bad weekday", "someday 17:00-20:00=3"},
+		{"bad window", "17:00=3"},
+		{"closed window", "closed=3"},
+		{"several windows", "10:00-12:00;17:00-20:00=3"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := entities.ParseRadiusRules(test.value)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_CalculationRequest_FindRestaurantsInRadius_RadiusRules(t *testing.T) {
+	rules, err := entities.ParseRadiusRules("17:00-20:00=1;sat 22:00-02:00=8")
+	require.NoError(t, err)
+	timeRadiusMap := entities.Restaurants{
+		{ID: "1", Lat: 51.5, Long: -0.1, Radius: 5, Schedule: everyDay("24h"), RadiusRules: rules},
+	}.CreateTimeRadiusMap()
+	candidates := []entities.RestaurantIDLatLng{{ID: "1", Lat: 51.5, Long: -0.1}}
+	// about 3 km north of the restaurant
+	lat := 51.527
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		radius   float64
+		expected []string
+	}{
+		{"regular radius", time.Date(2023, 10, 18, 12, 0, 0, 0, time.UTC), 5, []string{"1"}},
+		{"every day rule", time.Date(2023, 10, 18, 18, 0, 0, 0, time.UTC), 1, []string{}},
+		{"rule window end excluded", time.Date(2023, 10, 18, 20, 0, 0, 0, time.UTC), 5, []string{"1"}},
+		{"weekday rule", time.Date(2023, 10, 21, 23, 0, 0, 0, time.UTC), 8, []string{"1"}},
+		{"weekday rule past midnight", time.Date(2023, 10, 22, 1, 0, 0, 0, time.UTC), 8, []string{"1"}},
+		{"weekday rule on other days", time.Date(2023, 10, 20, 23, 0, 0, 0, time.UTC), 5, []string{"1"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := entities.CalculationRequest{Now: test.now, Lat: lat, Long: -0.1}
+
+			restaurants := request.FindRestaurantsInRadius(timeRadiusMap, nil, candidates)
+			explanation := request.Explain(timeRadiusMap, nil, candidates, 10)
+
+			assert.Equal(t, test.expected, request.NewCalculationResponse(restaurants).RestaurantIDs)
+			assert.Equal(t, test.radius, explanation.Candidates[0].DeliveryRadiusKm)
+		})
+	}
+
+	t.Run("max radius", func(t *testing.T) {
+		assert.Equal(t, 8.0, entities.Restaurant{Radius: 5, RadiusRules: rules}.MaxRadius())
+		assert.Equal(t, 5.0, entities.Restaurant{Radius: 5}.MaxRadius())
+	})
+}
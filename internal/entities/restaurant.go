@@ -1,128 +1,286 @@
 package entities
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"strconv"
+	"time"
 
-	"github.com/sebastianreh/distance-calculator-api/pkg/logger"
+	customCsv "github.com/sebastianreh/distance-calculator-api/pkg/csv"
+	"github.com/sebastianreh/distance-calculator-api/pkg/geozone"
+	"github.com/sebastianreh/distance-calculator-api/pkg/schedule"
 	str "github.com/sebastianreh/distance-calculator-api/pkg/strings"
 )
 
 const (
-	badlyFormattedErr = "CSV badly formatted"
-	bitSize           = 64
-	minRecords        = 2
-	minRecordSize     = 7
+	bitSize = 64
+	// limits accepted by the redis geo index
+	maxLatitude  = 85.05112878
+	maxLongitude = 180
+
+	ColumnID       = "id"
+	ColumnLat      = "latitude"
+	ColumnLong     = "longitude"
+	ColumnRadius   = "availability_radius"
+	ColumnOpen     = "open_hour"
+	ColumnClose    = "close_hour"
+	ColumnRating   = "rating"
+	ColumnHours    = "hours"
+	ColumnTimeZone = "timezone"
+	// ColumnRadiusRules is the optional column of radius rules, in the notation of ParseRadiusRules
+	ColumnRadiusRules = "radius_rules"
 )
 
 type Restaurant struct {
-	ID     string  `json:"id"`
-	Lat    float64 `json:"Lat"`
-	Long   float64 `json:"Long"`
-	Radius float64 `json:"Radius"`
-	Open   int     `json:"Open"`
-	Close  int     `json:"Close"`
-	Rating float64 `json:"Rating"`
+	ID     string         `json:"id"`
+	Lat    float64        `json:"Lat"`
+	Long   float64        `json:"Long"`
+	Radius float64        `json:"Radius"`
+	Open   schedule.Clock `json:"Open"`
+	Close  schedule.Clock `json:"Close"`
+	Rating float64        `json:"Rating"`
+	// Schedule starts from Open and Close for every day, or the optional hours column, overridden by the
+	// optional weekday columns
+	Schedule schedule.Week `json:"Schedule"`
+	// TimeZone is the IANA time zone the schedule is expressed in
+	TimeZone string `json:"TimeZone"`
+	// RadiusRules replace Radius within their time windows, the first one applying wins
+	RadiusRules []RadiusRule `json:"RadiusRules,omitempty"`
+	// Zone, read from the delivery zones file, replaces Radius and RadiusRules when present
+	Zone *geozone.Zone `json:"Zone,omitempty"`
+	// Metadata keeps the values of the CSV columns that are not part of the model, keyed by header name
+	Metadata map[string]string `json:"Metadata,omitempty"`
 }
 
 type Restaurants []Restaurant
 
-func MapRecordsToRestaurants(records [][]string, logs logger.Logger) (Restaurants, error) {
-	var restaurants Restaurants
-	if len(records) <= minRecords {
-		return restaurants, errors.New("records len is less than 2")
-	}
-	for i, record := range records {
-		if i == 0 {
-			err := checkCsvFormat(record)
-			if err != nil {
-				return restaurants, err
-			}
-			continue
-		}
+// RowError is returned by RestaurantDecoder.Next for a row that cannot be mapped to a restaurant.
+// Decoding can continue with the next row.
+type RowError struct {
+	Line   int
+	Column string
+	Value  string
+	Reason string
+}
 
-		restaurant, err := processRestaurantRecord(record)
-		if err != nil {
-			logs.Warn(str.ErrorConcat(err, "entities.restaurant", "MapRecordsToRestaurants"))
-			continue
+func (e *RowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+	}
+	return fmt.Sprintf("line %d, column %s: %s (%q)", e.Line, e.Column, e.Reason, e.Value)
+}
+
+func newColumnError(column, value, reason string) *RowError {
+	return &RowError{Column: column, Value: value, Reason: reason}
+}
+
+// RestaurantDecoder maps a restaurants CSV stream to restaurants one row at a time
+type RestaurantDecoder struct {
+	reader          *customCsv.RecordReader
+	layout          columnLayout
+	defaultTimeZone string
+}
+
+// NewRestaurantDecoder reads the header of the stream and resolves the position of every column from it.
+// Columns can come in any order and be named after any of their aliases; unknown columns are kept as
+// restaurant metadata. Restaurants without a timezone value get defaultTimeZone.
+func NewRestaurantDecoder(reader *customCsv.RecordReader, aliases ColumnAliases,
+	defaultTimeZone string) (*RestaurantDecoder, error) {
+	if _, err := schedule.LoadLocation(defaultTimeZone); err != nil {
+		return nil, fmt.Errorf("invalid default time zone: %w", err)
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("restaurants CSV is empty")
 		}
+		return nil, err
+	}
 
-		restaurants = append(restaurants, restaurant)
+	layout, err := newColumnLayout(header, aliases)
+	if err != nil {
+		return nil, err
 	}
 
-	return restaurants, nil
+	return &RestaurantDecoder{reader: reader, layout: layout, defaultTimeZone: defaultTimeZone}, nil
 }
 
-func checkCsvFormat(record []string) error {
-	if len(record) != minRecordSize {
-		return errors.New(badlyFormattedErr)
+// Next returns the next restaurant of the stream, io.EOF when there are no more rows or a *RowError
+// when the current row is invalid
+func (d *RestaurantDecoder) Next() (Restaurant, error) {
+	record, err := d.reader.Read()
+	if err != nil {
+		if customCsv.IsParseError(err) {
+			return Restaurant{}, &RowError{Line: d.reader.Line(), Reason: fmt.Sprintf("malformed CSV row: %s", err)}
+		}
+		return Restaurant{}, err
 	}
 
-	if record[0] != "id" || record[1] != "latitude" || record[2] != "longitude" ||
-		record[3] != "availability_radius" || record[4] != "open_hour" || record[5] != "close_hour" ||
-		record[6] != "rating" {
-		return errors.New(badlyFormattedErr)
+	restaurant, rowErr := processRestaurantRecord(record, d.layout, d.defaultTimeZone)
+	if rowErr != nil {
+		rowErr.Line = d.reader.Line()
+		return Restaurant{}, rowErr
 	}
 
-	return nil
+	return restaurant, nil
+}
+
+// Line returns the line where the last decoded row starts
+func (d *RestaurantDecoder) Line() int {
+	return d.reader.Line()
 }
 
-func processRestaurantRecord(record []string) (Restaurant, error) {
-	if len(record) < minRecordSize {
-		return Restaurant{}, errors.New(badlyFormattedErr)
+func processRestaurantRecord(record []string, layout columnLayout, defaultTimeZone string) (Restaurant, *RowError) {
+	if len(record) < layout.minFields {
+		return Restaurant{}, &RowError{Reason: fmt.Sprintf("expected at least %d columns, found %d", layout.minFields, len(record))}
 	}
 
-	lat, err := strconv.ParseFloat(record[1], bitSize)
-	if err != nil {
-		return Restaurant{}, errors.New(badlyFormattedErr)
+	id := layout.value(record, ColumnID)
+	if str.IsEmpty(id) {
+		return Restaurant{}, newColumnError(ColumnID, id, "missing id")
 	}
 
-	long, err := strconv.ParseFloat(record[2], bitSize)
-	if err != nil {
-		return Restaurant{}, errors.New(badlyFormattedErr)
+	lat, rowErr := parseCoordinate(ColumnLat, layout.value(record, ColumnLat), maxLatitude)
+	if rowErr != nil {
+		return Restaurant{}, rowErr
+	}
+
+	long, rowErr := parseCoordinate(ColumnLong, layout.value(record, ColumnLong), maxLongitude)
+	if rowErr != nil {
+		return Restaurant{}, rowErr
 	}
 
-	radius, err := strconv.ParseFloat(record[3], bitSize)
+	rawRadius := layout.value(record, ColumnRadius)
+	radius, err := strconv.ParseFloat(rawRadius, bitSize)
 	if err != nil {
-		return Restaurant{}, errors.New(badlyFormattedErr)
+		return Restaurant{}, newColumnError(ColumnRadius, rawRadius, "unparseable radius")
+	}
+	if radius < 0 {
+		return Restaurant{}, newColumnError(ColumnRadius, rawRadius, "negative radius")
 	}
 
-	openHour, err := str.TimeToInt(record[4])
+	rawOpen := layout.value(record, ColumnOpen)
+	openHour, err := schedule.ParseOpeningClock(rawOpen)
 	if err != nil {
-		return Restaurant{}, errors.New(badlyFormattedErr)
+		return Restaurant{}, newColumnError(ColumnOpen, rawOpen, err.Error())
 	}
 
-	closeHour, err := str.TimeToInt(record[5])
+	rawClose := layout.value(record, ColumnClose)
+	closeHour, err := schedule.ParseClock(rawClose)
 	if err != nil {
-		return Restaurant{}, errors.New(badlyFormattedErr)
+		return Restaurant{}, newColumnError(ColumnClose, rawClose, "bad time format, expected HH:MM")
 	}
 
-	rating, err := strconv.ParseFloat(record[6], bitSize)
+	rawRating := layout.value(record, ColumnRating)
+	rating, err := strconv.ParseFloat(rawRating, bitSize)
 	if err != nil {
-		return Restaurant{}, errors.New(badlyFormattedErr)
+		return Restaurant{}, newColumnError(ColumnRating, rawRating, "unparseable rating")
+	}
+
+	defaultDay := schedule.Day{Intervals: []schedule.Interval{{Open: openHour, Close: closeHour}}}
+	if rawHours := layout.value(record, ColumnHours); rawHours != "" {
+		defaultDay, err = schedule.ParseDay(rawHours)
+		if err != nil {
+			return Restaurant{}, newColumnError(ColumnHours, rawHours, err.Error())
+		}
+	}
+
+	weeklySchedule := schedule.NewWeek(defaultDay)
+	for weekday := range weeklySchedule {
+		column := WeekdayColumn(time.Weekday(weekday))
+		rawDay := layout.value(record, column)
+		if rawDay == "" {
+			continue
+		}
+		weeklySchedule[weekday], err = schedule.ParseDay(rawDay)
+		if err != nil {
+			return Restaurant{}, newColumnError(column, rawDay, err.Error())
+		}
+	}
+
+	timeZone := layout.value(record, ColumnTimeZone)
+	if timeZone == "" {
+		timeZone = defaultTimeZone
+	} else if _, err = schedule.LoadLocation(timeZone); err != nil {
+		return Restaurant{}, newColumnError(ColumnTimeZone, timeZone, "unknown IANA time zone")
+	}
+
+	var radiusRules []RadiusRule
+	if rawRules := layout.value(record, ColumnRadiusRules); rawRules != "" {
+		radiusRules, err = ParseRadiusRules(rawRules)
+		if err != nil {
+			return Restaurant{}, newColumnError(ColumnRadiusRules, rawRules, err.Error())
+		}
 	}
 
 	restaurant := Restaurant{
-		ID:     record[0],
-		Lat:    lat,
-		Long:   long,
-		Radius: radius,
-		Open:   openHour,
-		Close:  closeHour,
-		Rating: rating,
+		ID:       id,
+		Lat:      lat,
+		Long:     long,
+		Radius:   radius,
+		Open:     openHour,
+		Close:    closeHour,
+		Rating:   rating,
+		Schedule: weeklySchedule,
+		TimeZone: timeZone,
+		Metadata: layout.metadata(record),
+
+		RadiusRules: radiusRules,
 	}
 
 	return restaurant, nil
 }
 
+func parseCoordinate(column, value string, limit float64) (float64, *RowError) {
+	coordinate, err := strconv.ParseFloat(value, bitSize)
+	if err != nil {
+		return 0, newColumnError(column, value, fmt.Sprintf("unparseable %s", column))
+	}
+	if coordinate < -limit || coordinate > limit {
+		return 0, newColumnError(column, value, fmt.Sprintf("%s out of range [-%g, %g]", column, limit, limit))
+	}
+
+	return coordinate, nil
+}
+
 func (r Restaurants) CreateTimeRadiusMap() TimeRadiusMap {
 	timeScheduleMap := make(TimeRadiusMap)
 	for _, rest := range r {
-		timeScheduleMap[rest.ID] = timeRadiusSchedule{
-			Open:   rest.Open,
-			Close:  rest.Close,
-			Radius: rest.Radius,
-		}
+		timeScheduleMap.Add(rest)
 	}
 	return timeScheduleMap
 }
+
+func (m TimeRadiusMap) Add(restaurant Restaurant) {
+	m[restaurant.ID] = timeRadiusSchedule{
+		Lat:      restaurant.Lat,
+		Long:     restaurant.Long,
+		Schedule: restaurant.Schedule,
+		TimeZone: restaurant.TimeZone,
+		Radius:   restaurant.Radius,
+		Rating:   restaurant.Rating,
+		Metadata: restaurant.Metadata,
+
+		RadiusRules: restaurant.RadiusRules,
+		Zone:        restaurant.Zone,
+		ZoneRadius:  restaurant.ZoneRadius(),
+	}
+}
+
+// EncodeEntry encodes the attributes of one restaurant, so the map can be stored as one value per restaurant
+func (m TimeRadiusMap) EncodeEntry(id string) ([]byte, error) {
+	return json.Marshal(m[id])
+}
+
+// DecodeEntry adds a restaurant from attributes encoded with EncodeEntry
+func (m TimeRadiusMap) DecodeEntry(id string, data []byte) error {
+	var entry timeRadiusSchedule
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return err
+	}
+	m[id] = entry
+	return nil
+}
@@ -0,0 +1,141 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	aliasSeparator = "|"
+	byteOrderMark  = "\ufeff"
+)
+
+var (
+	requiredColumns = []string{ColumnID, ColumnLat, ColumnLong, ColumnRadius, ColumnOpen, ColumnClose, ColumnRating}
+	optionalColumns = append([]string{ColumnHours, ColumnTimeZone, ColumnRadiusRules}, weekdayColumns()...)
+)
+
+func weekdayColumns() []string {
+	var columns []string
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		columns = append(columns, WeekdayColumn(weekday))
+	}
+	return columns
+}
+
+// WeekdayColumn is the optional CSV column holding the schedule of the given weekday, e.g. monday
+func WeekdayColumn(weekday time.Weekday) string {
+	return strings.ToLower(weekday.String())
+}
+
+// ColumnAliases maps a canonical column name to the alternative header names accepted for it
+type ColumnAliases map[string][]string
+
+// ParseColumnAliases reads aliases configured as canonical:alias1|alias2 pairs
+func ParseColumnAliases(raw map[string]string) ColumnAliases {
+	aliases := make(ColumnAliases, len(raw))
+	for column, names := range raw {
+		column = normalizeColumnName(column)
+		for _, name := range strings.Split(names, aliasSeparator) {
+			if name = normalizeColumnName(name); name != "" {
+				aliases[column] = append(aliases[column], name)
+			}
+		}
+	}
+	return aliases
+}
+
+// columnLayout knows where every column of a restaurants CSV is, based on its header
+type columnLayout struct {
+	positions    map[string]int
+	extraColumns map[string]int
+	minFields    int
+}
+
+func newColumnLayout(header []string, aliases ColumnAliases) (columnLayout, error) {
+	canonicalNames := make(map[string]string)
+	for _, column := range append(append([]string{}, requiredColumns...), optionalColumns...) {
+		canonicalNames[column] = column
+		for _, alias := range aliases[column] {
+			canonicalNames[alias] = column
+		}
+	}
+
+	layout := columnLayout{
+		positions:    make(map[string]int),
+		extraColumns: make(map[string]int),
+	}
+	for index, name := range header {
+		name = normalizeColumnName(name)
+		if name == "" {
+			continue
+		}
+
+		column, known := canonicalNames[name]
+		if !known {
+			if _, repeated := layout.extraColumns[name]; repeated {
+				return layout, fmt.Errorf("CSV column %s appears more than once", name)
+			}
+			layout.extraColumns[name] = index
+			continue
+		}
+		if _, repeated := layout.positions[column]; repeated {
+			return layout, fmt.Errorf("CSV column %s appears more than once", column)
+		}
+		layout.positions[column] = index
+	}
+
+	for _, column := range requiredColumns {
+		if index, ok := layout.positions[column]; ok && index >= layout.minFields {
+			layout.minFields = index + 1
+		}
+	}
+
+	var missing []string
+	for _, column := range requiredColumns {
+		if _, ok := layout.positions[column]; !ok {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return layout, fmt.Errorf("CSV is missing required columns: %s", strings.Join(missing, ", "))
+	}
+
+	return layout, nil
+}
+
+// value returns the trimmed value of a column, or an empty string for optional columns missing in the CSV
+func (l columnLayout) value(record []string, column string) string {
+	index, ok := l.positions[column]
+	if !ok || index >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[index])
+}
+
+// metadata returns the non empty values of the columns that are not part of the restaurant model
+func (l columnLayout) metadata(record []string) map[string]string {
+	if len(l.extraColumns) == 0 {
+		return nil
+	}
+
+	var metadata map[string]string
+	for name, index := range l.extraColumns {
+		if index >= len(record) {
+			continue
+		}
+		if value := strings.TrimSpace(record[index]); value != "" {
+			if metadata == nil {
+				metadata = make(map[string]string)
+			}
+			metadata[name] = value
+		}
+	}
+
+	return metadata
+}
+
+func normalizeColumnName(name string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, byteOrderMark)))
+}
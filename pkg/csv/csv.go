@@ -1,30 +1,46 @@
 package csv
 
 import (
-	"bytes"
 	"encoding/csv"
-	"errors"
+	"io"
 )
 
-const (
-	minRecords = 2
-)
+// RecordReader reads a CSV stream one record at a time, so callers never hold the whole file in memory
+type RecordReader struct {
+	reader *csv.Reader
+	line   int
+}
+
+func NewRecordReader(reader io.Reader) *RecordReader {
+	csvReader := csv.NewReader(reader)
+	// rows with a wrong amount of fields are rejected by the caller instead of aborting the whole stream
+	csvReader.FieldsPerRecord = -1
+	csvReader.ReuseRecord = true
+
+	return &RecordReader{reader: csvReader}
+}
 
-func CsvBytesToRecords(csvBytes []byte) ([][]string, error) {
-	var records [][]string
-	reader := csv.NewReader(bytes.NewReader(csvBytes))
-	for {
-		record, err := reader.Read()
-		if err != nil {
-			if err.Error() != "EOF" {
-				return records, err
-			}
-			break
-		}
-		records = append(records, record)
+// Read returns the next record, or io.EOF once the stream is exhausted. The returned slice is reused
+// by the next call. A *csv.ParseError only affects the current record and reading can continue.
+func (r *RecordReader) Read() ([]string, error) {
+	record, err := r.reader.Read()
+	if parseErr, ok := err.(*csv.ParseError); ok {
+		r.line = parseErr.StartLine
+		return record, err
 	}
-	if len(records) < minRecords {
-		return records, errors.New("error reading csv bytes - records < 2")
+	if err == nil {
+		r.line, _ = r.reader.FieldPos(0)
 	}
-	return records, nil
+
+	return record, err
+}
+
+// Line returns the line where the last read record starts
+func (r *RecordReader) Line() int {
+	return r.line
+}
+
+func IsParseError(err error) bool {
+	_, ok := err.(*csv.ParseError)
+	return ok
 }
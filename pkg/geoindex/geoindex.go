@@ -0,0 +1,126 @@
+package geoindex
+
+import (
+	"math"
+
+	mathFormulas "github.com/sebastianreh/distance-calculator-api/pkg/math_formulas"
+)
+
+const (
+	// bits per axis of the grid cells, the same cells as 5 character geohashes: about 4.9 km x 4.9 km at the
+	// equator, narrower towards the poles
+	cellBits  = 12
+	longBits  = cellBits + 1
+	cells     = 1 << cellBits
+	longCells = 1 << longBits
+	maxLat    = 90
+	maxLong   = 180
+	degrees   = 180
+)
+
+// Point is an indexed location
+type Point struct {
+	ID   string
+	Lat  float64
+	Long float64
+}
+
+// Grid is an immutable geohash grid index of points, safe for concurrent searches
+type Grid struct {
+	cells map[uint64][]Point
+	size  int
+}
+
+func NewGrid(points []Point) *Grid {
+	grid := &Grid{cells: make(map[uint64][]Point), size: len(points)}
+	for _, point := range points {
+		key := cellKey(latCell(point.Lat), longCell(point.Long))
+		grid.cells[key] = append(grid.cells[key], point)
+	}
+	return grid
+}
+
+func (g *Grid) Len() int {
+	return g.size
+}
+
+// Search returns the points within radius km of the given location by Haversine distance. Only the cells
+// overlapping the bounding box of the circle are scanned, or every point when the box covers more cells than
+// there are points.
+func (g *Grid) Search(lat, long, radius float64) []Point {
+	var found []Point
+	if math.Abs(lat) > maxLat || math.Abs(long) > maxLong || radius < 0 {
+		return found
+	}
+
+	latDelta := mathFormulas.KmToDegrees(radius)
+	minLat, maxLatBox := math.Max(lat-latDelta, -maxLat), math.Min(lat+latDelta, maxLat)
+	longDelta := float64(maxLong)
+	// the box spans every longitude when it reaches a pole
+	if cosLat := math.Cos(math.Max(math.Abs(minLat), math.Abs(maxLatBox)) * math.Pi / degrees); cosLat > 0 {
+		longDelta = math.Min(latDelta/cosLat, maxLong)
+	}
+
+	fromLat, toLat := latCell(minLat), latCell(maxLatBox)
+	fromLong, toLong := longCell(long-longDelta), longCell(long+longDelta)
+	// a box crossing the antimeridian starts on its last cells and wraps around to the first ones
+	longSpan := (toLong-fromLong+longCells)%longCells + 1
+	if longDelta >= maxLong {
+		fromLong, longSpan = 0, longCells
+	}
+
+	within := func(point Point) {
+		if mathFormulas.Haversine(lat, long, point.Lat, point.Long) <= radius {
+			found = append(found, point)
+		}
+	}
+
+	if (toLat-fromLat+1)*longSpan > g.size {
+		for _, points := range g.cells {
+			for _, point := range points {
+				within(point)
+			}
+		}
+		return found
+	}
+
+	for y := fromLat; y <= toLat; y++ {
+		for i := 0; i < longSpan; i++ {
+			x := (fromLong + i) % longCells
+			for _, point := range g.cells[cellKey(y, x)] {
+				within(point)
+			}
+		}
+	}
+
+	return found
+}
+
+func latCell(lat float64) int {
+	return axisCell(lat+maxLat, 2*maxLat, cells)
+}
+
+// longCell maps longitudes out of range, from boxes crossing the antimeridian, to the cells they wrap to
+func longCell(long float64) int {
+	if long < -maxLong {
+		long += 2 * maxLong
+	} else if long > maxLong {
+		long -= 2 * maxLong
+	}
+	return axisCell(long+maxLong, 2*maxLong, longCells)
+}
+
+func axisCell(offset, span float64, count int) int {
+	cell := int(offset / span * float64(count))
+	if cell >= count {
+		return count - 1
+	}
+	if cell < 0 {
+		return 0
+	}
+	return cell
+}
+
+func cellKey(y, x int) uint64 {
+	return uint64(y)<<longBits | uint64(x)
+}
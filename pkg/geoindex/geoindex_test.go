@@ -0,0 +1,83 @@
+package geoindex_test
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/sebastianreh/distance-calculator-api/pkg/geoindex"
+	mathFormulas "github.com/sebastianreh/distance-calculator-api/pkg/math_formulas"
+	"github.com/stretchr/testify/assert"
+)
+
+func randomPoints(random *rand.Rand, count int, lat, long, spread float64) []geoindex.Point {
+	points := make([]geoindex.Point, 0, count)
+	for i := 0; i < count; i++ {
+		pointLong := long + (random.Float64()*2-1)*spread
+		if pointLong > 180 {
+			pointLong -= 360
+		} else if pointLong < -180 {
+			pointLong += 360
+		}
+		points = append(points, geoindex.Point{
+			ID:   fmt.Sprintf("%d", i),
+			Lat:  lat + (random.Float64()*2-1)*spread,
+			Long: pointLong,
+		})
+	}
+	return points
+}
+
+func ids(points []geoindex.Point) []string {
+	found := make([]string, 0, len(points))
+	for _, point := range points {
+		found = append(found, point.ID)
+	}
+	sort.Strings(found)
+	return found
+}
+
+func Test_Grid_Search(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		name   string
+		lat    float64
+		long   float64
+		spread float64
+		radius float64
+	}{
+		{name: "city", lat: 51.5, long: -0.12, spread: 0.2, radius: 6},
+		{name: "small radius", lat: -34.6, long: -58.38, spread: 0.05, radius: 0.5},
+		{name: "large radius", lat: 40.7, long: -74, spread: 2, radius: 120},
+		{name: "antimeridian", lat: -17.7, long: 179.95, spread: 0.3, radius: 20},
+		{name: "near the pole", lat: 84.9, long: 10, spread: 0.1, radius: 15},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points := randomPoints(random, 2000, tt.lat, tt.long, tt.spread)
+			grid := geoindex.NewGrid(points)
+
+			for i := 0; i < 20; i++ {
+				center := randomPoints(random, 1, tt.lat, tt.long, tt.spread)[0]
+				var expected []geoindex.Point
+				for _, point := range points {
+					if mathFormulas.Haversine(center.Lat, center.Long, point.Lat, point.Long) <= tt.radius {
+						expected = append(expected, point)
+					}
+				}
+
+				assert.Equal(t, ids(expected), ids(grid.Search(center.Lat, center.Long, tt.radius)))
+			}
+		})
+	}
+
+	t.Run("invalid location", func(t *testing.T) {
+		grid := geoindex.NewGrid([]geoindex.Point{{ID: "1", Lat: 10, Long: 10}})
+
+		assert.Empty(t, grid.Search(95, 10, 10))
+		assert.Equal(t, 1, grid.Len())
+	})
+}
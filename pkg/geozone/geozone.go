@@ -0,0 +1,167 @@
+package geozone
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	mathFormulas "github.com/sebastianreh/distance-calculator-api/pkg/math_formulas"
+)
+
+const (
+	TypePolygon      = "Polygon"
+	TypeMultiPolygon = "MultiPolygon"
+
+	// a closed ring repeats its first position at the end, so a triangle has four
+	minRingPositions = 4
+	maxLatitude      = 90
+	maxLongitude     = 180
+)
+
+var ErrEmptyZone = errors.New("zone has no polygons")
+
+// Position is a GeoJSON position, longitude first
+type Position [2]float64
+
+func (p Position) Long() float64 {
+	return p[0]
+}
+
+func (p Position) Lat() float64 {
+	return p[1]
+}
+
+// Ring is a closed linear ring
+type Ring []Position
+
+// Polygon is an outer ring followed by the rings of its holes
+type Polygon []Ring
+
+// Zone is an area made of one or more polygons, read from a GeoJSON Polygon or MultiPolygon geometry. Polygons
+// are evaluated on the plane of their coordinates, accurate enough at the scale of a delivery area.
+type Zone struct {
+	Polygons []Polygon
+}
+
+type geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// MarshalJSON encodes the zone as a GeoJSON MultiPolygon geometry
+func (z Zone) MarshalJSON() ([]byte, error) {
+	coordinates, err := json.Marshal(z.Polygons)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(geometry{Type: TypeMultiPolygon, Coordinates: coordinates})
+}
+
+// UnmarshalJSON decodes a GeoJSON Polygon or MultiPolygon geometry, rejecting rings that are not closed or have
+// positions out of range
+func (z *Zone) UnmarshalJSON(data []byte) error {
+	var decoded geometry
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	var polygons []Polygon
+	switch decoded.Type {
+	case TypePolygon:
+		var polygon Polygon
+		if err := json.Unmarshal(decoded.Coordinates, &polygon); err != nil {
+			return err
+		}
+		polygons = []Polygon{polygon}
+	case TypeMultiPolygon:
+		if err := json.Unmarshal(decoded.Coordinates, &polygons); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported geometry type %q, expected %s or %s", decoded.Type, TypePolygon,
+			TypeMultiPolygon)
+	}
+
+	zone := Zone{Polygons: polygons}
+	if err := zone.validate(); err != nil {
+		return err
+	}
+
+	*z = zone
+	return nil
+}
+
+func (z Zone) validate() error {
+	if len(z.Polygons) == 0 {
+		return ErrEmptyZone
+	}
+
+	for _, polygon := range z.Polygons {
+		if len(polygon) == 0 {
+			return errors.New("polygon has no rings")
+		}
+		for _, ring := range polygon {
+			if len(ring) < minRingPositions {
+				return fmt.Errorf("ring has %d positions, at least %d are required", len(ring), minRingPositions)
+			}
+			if ring[0] != ring[len(ring)-1] {
+				return errors.New("ring is not closed, its last position must repeat the first one")
+			}
+			for _, position := range ring {
+				if math.Abs(position.Lat()) > maxLatitude || math.Abs(position.Long()) > maxLongitude {
+					return fmt.Errorf("position %v out of range", position)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Contains tells if the point falls within any polygon of the zone and outside its holes
+func (z Zone) Contains(lat, long float64) bool {
+	for _, polygon := range z.Polygons {
+		if polygon.contains(lat, long) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Polygon) contains(lat, long float64) bool {
+	if !p[0].contains(lat, long) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if hole.contains(lat, long) {
+			return false
+		}
+	}
+	return true
+}
+
+// contains casts a ray from the point towards growing longitudes and counts the edges it crosses
+func (r Ring) contains(lat, long float64) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		a, b := r[i], r[j]
+		if (a.Lat() > lat) != (b.Lat() > lat) &&
+			long < (b.Long()-a.Long())*(lat-a.Lat())/(b.Lat()-a.Lat())+a.Long() {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// BoundingRadius is the distance in km from the point to the farthest position of the zone's outer rings, so the
+// circle of that radius around the point covers the whole zone
+func (z Zone) BoundingRadius(lat, long float64) float64 {
+	var radius float64
+	for _, polygon := range z.Polygons {
+		for _, position := range polygon[0] {
+			radius = math.Max(radius, mathFormulas.Haversine(lat, long, position.Lat(), position.Long()))
+		}
+	}
+	return radius
+}
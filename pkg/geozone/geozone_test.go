@@ -0,0 +1,102 @@
+package geozone_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sebastianreh/distance-calculator-api/pkg/geozone"
+	mathFormulas "github.com/sebastianreh/distance-calculator-api/pkg/math_formulas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// a square around central London with a square hole, and a separate triangle to the east
+const multiPolygon = `{"type": "MultiPolygon", "coordinates": [
+	[
+		[[-0.2, 51.45], [0, 51.45], [0, 51.55], [-0.2, 51.55], [-0.2, 51.45]],
+		[[-0.12, 51.49], [-0.08, 51.49], [-0.08, 51.51], [-0.12, 51.51], [-0.12, 51.49]]
+	],
+	[
+		[[0.1, 51.45], [0.2, 51.45], [0.15, 51.55], [0.1, 51.45]]
+	]
+]}`
+
+func Test_Zone_Contains(t *testing.T) {
+	var zone geozone.Zone
+	require.NoError(t, json.Unmarshal([]byte(multiPolygon), &zone))
+
+	tests := []struct {
+		name     string
+		lat      float64
+		long     float64
+		expected bool
+	}{
+		{"inside the first polygon", 51.47, -0.15, true},
+		{"inside the hole", 51.5, -0.1, false},
+		{"inside the second polygon", 51.47, 0.15, true},
+		{"between polygons", 51.5, 0.05, false},
+		{"outside the triangle corner", 51.54, 0.11, false},
+		{"north of every polygon", 51.6, -0.1, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, zone.Contains(test.lat, test.long))
+		})
+	}
+}
+
+func Test_Zone_UnmarshalJSON(t *testing.T) {
+	t.Run("polygon", func(t *testing.T) {
+		var zone geozone.Zone
+		err := json.Unmarshal([]byte(`{"type": "Polygon", "coordinates": [
+			[[-0.2, 51.45], [0, 51.45], [0, 51.55], [-0.2, 51.45]]]}`), &zone)
+
+		assert.NoError(t, err)
+		assert.Len(t, zone.Polygons, 1)
+	})
+
+	t.Run("round trip as a multipolygon", func(t *testing.T) {
+		var zone, decoded geozone.Zone
+		require.NoError(t, json.Unmarshal([]byte(multiPolygon), &zone))
+
+		data, err := json.Marshal(zone)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &decoded))
+
+		assert.Equal(t, zone, decoded)
+		assert.Contains(t, string(data), `"type":"MultiPolygon"`)
+	})
+
+	tests := []struct {
+		name     string
+		geometry string
+	}{
+		{"unsupported type", `{"type": "Point", "coordinates": [-0.1, 51.5]}`},
+		{"no polygons", `{"type": "MultiPolygon", "coordinates": []}`},
+		{"ring too short", `{"type": "Polygon", "coordinates": [[[-0.2, 51.45], [0, 51.45], [-0.2, 51.45]]]}`},
+		{"ring not closed", `{"type": "Polygon", "coordinates": [[[-0.2, 51.45], [0, 51.45], [0, 51.55], [0, 51.6]]]}`},
+		{"position out of range", `{"type": "Polygon", "coordinates": [[[-0.2, 91], [0, 51.45], [0, 51.55], [-0.2, 91]]]}`},
+		{"bad coordinates", `{"type": "Polygon", "coordinates": "somewhere"}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var zone geozone.Zone
+
+			assert.Error(t, json.Unmarshal([]byte(test.geometry), &zone))
+		})
+	}
+}
+
+func Test_Zone_BoundingRadius(t *testing.T) {
+	var zone geozone.Zone
+	require.NoError(t, json.Unmarshal([]byte(multiPolygon), &zone))
+
+	radius := zone.BoundingRadius(51.5, -0.1)
+
+	assert.InDelta(t, mathFormulas.Haversine(51.5, -0.1, 51.45, 0.2), radius, 1e-9)
+	for _, point := range [][2]float64{{51.47, -0.15}, {51.47, 0.15}, {51.54, -0.19}} {
+		assert.LessOrEqual(t, mathFormulas.Haversine(51.5, -0.1, point[0], point[1]), radius)
+	}
+}
@@ -13,18 +13,40 @@ import (
 )
 
 const (
-	emptyString = ""
+	emptyString          = ""
+	defaultBulkChunkSize = 500
 )
 
+// GeoMember is a location indexed under its bare id, as written by GeoAddBulk and found by GeoSearch
+type GeoMember struct {
+	ID   string
+	Lat  float64
+	Long float64
+}
+
 type Redis interface {
 	Set(ctx context.Context, key string, value any, ttl time.Duration) error
 	Get(ctx context.Context, key string) (string, error)
-	GeoAdd(ctx context.Context, key, id string, lat, long, radius float64) error
-	GeoSearch(ctx context.Context, key string, lat, long, radius float64) ([]string, error)
+	GeoAdd(ctx context.Context, key, id string, lat, long float64) error
+	GeoAddBulk(ctx context.Context, key string, members []GeoMember) error
+	GeoSearch(ctx context.Context, key string, lat, long, radius float64) ([]GeoMember, error)
+	HSet(ctx context.Context, key, field, value string) error
+	HSetBulk(ctx context.Context, key string, fields map[string]string) error
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	HDel(ctx context.Context, key string, fields ...string) (int64, error)
+	Del(ctx context.Context, keys ...string) error
+	Expire(ctx context.Context, key string, ttl time.Duration) error
+	ZAdd(ctx context.Context, key, member string, score float64) error
+	ZRange(ctx context.Context, key string) ([]string, error)
+	ZRem(ctx context.Context, key string, members ...string) error
+	Publish(ctx context.Context, channel, message string) error
+	Subscribe(ctx context.Context, channel string) (<-chan string, error)
 }
 
 type redis struct {
 	client *rd.Client
+	// members or fields per command of bulk writes
+	bulkChunkSize int
 }
 
 func NewRedis(log logger.Logger, cfg config.Config) (Redis, error) {
@@ -41,8 +63,14 @@ func NewRedis(log logger.Logger, cfg config.Config) (Redis, error) {
 		log.Info(fmt.Sprintf("Redis => Monitoring | Connected successfully to %s", cfg.Redis.Host), "")
 	}
 
+	bulkChunkSize := cfg.Redis.GeoAddChunkSize
+	if bulkChunkSize <= 0 {
+		bulkChunkSize = defaultBulkChunkSize
+	}
+
 	return &redis{
-		client: client,
+		client:        client,
+		bulkChunkSize: bulkChunkSize,
 	}, nil
 }
 
@@ -79,16 +107,10 @@ func (r *redis) Get(ctx context.Context, key string) (string, error) {
 	return status.Val(), nil
 }
 
-func (r *redis) GeoAdd(ctx context.Context, key, id string, lat, long, radius float64) error {
-	geoLocation := rd.GeoLocation{
-		Name:      fmt.Sprintf("%s-%f-%f-%f", id, lat, long, radius),
-		Longitude: long,
-		Latitude:  lat,
-		Dist:      0,
-		GeoHash:   0,
-	}
+func (r *redis) GeoAdd(ctx context.Context, key, id string, lat, long float64) error {
+	geoLocation := newGeoLocation(GeoMember{ID: id, Lat: lat, Long: long})
 
-	status := r.client.GeoAdd(ctx, key, &geoLocation)
+	status := r.client.GeoAdd(ctx, key, geoLocation)
 
 	if status.Err() != nil {
 		return status.Err()
@@ -97,17 +119,172 @@ func (r *redis) GeoAdd(ctx context.Context, key, id string, lat, long, radius fl
 	return nil
 }
 
-func (r *redis) GeoSearch(ctx context.Context, key string, lat, long, radius float64) ([]string, error) {
-	geoSearch := rd.GeoSearchQuery{
-		Longitude:  long,
-		Latitude:   lat,
-		Radius:     radius,
-		RadiusUnit: "km",
+// GeoAddBulk adds all members sending one multi-member GEOADD per chunk, with every chunk queued in a
+// single pipeline so the whole call costs one network round trip
+func (r *redis) GeoAddBulk(ctx context.Context, key string, members []GeoMember) error {
+	if len(members) == 0 {
+		return nil
+	}
+
+	pipeline := r.client.Pipeline()
+	for start := 0; start < len(members); start += r.bulkChunkSize {
+		end := start + r.bulkChunkSize
+		if end > len(members) {
+			end = len(members)
+		}
+
+		geoLocations := make([]*rd.GeoLocation, 0, end-start)
+		for _, member := range members[start:end] {
+			geoLocations = append(geoLocations, newGeoLocation(member))
+		}
+		pipeline.GeoAdd(ctx, key, geoLocations...)
+	}
+
+	_, err := pipeline.Exec(ctx)
+	return err
+}
+
+func newGeoLocation(member GeoMember) *rd.GeoLocation {
+	return &rd.GeoLocation{
+		Name:      member.ID,
+		Longitude: member.Long,
+		Latitude:  member.Lat,
+		Dist:      0,
+		GeoHash:   0,
+	}
+}
+
+// GeoSearch returns the members within radius km of the location, with their coordinates
+func (r *redis) GeoSearch(ctx context.Context, key string, lat, long, radius float64) ([]GeoMember, error) {
+	geoSearch := rd.GeoSearchLocationQuery{
+		GeoSearchQuery: rd.GeoSearchQuery{
+			Longitude:  long,
+			Latitude:   lat,
+			Radius:     radius,
+			RadiusUnit: "km",
+		},
+		WithCoord: true,
+	}
+	status := r.client.GeoSearchLocation(ctx, key, &geoSearch)
+	if status.Err() != nil && status.Err() != rd.Nil {
+		return []GeoMember{}, status.Err()
+	}
+
+	members := make([]GeoMember, 0, len(status.Val()))
+	for _, location := range status.Val() {
+		members = append(members, GeoMember{ID: location.Name, Lat: location.Latitude, Long: location.Longitude})
+	}
+
+	return members, nil
+}
+
+func (r *redis) HSet(ctx context.Context, key, field, value string) error {
+	return r.client.HSet(ctx, key, field, value).Err()
+}
+
+// HSetBulk sets all fields sending one multi-field HSET per chunk, in a single pipeline
+func (r *redis) HSetBulk(ctx context.Context, key string, fields map[string]string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	pipeline := r.client.Pipeline()
+	values := make([]interface{}, 0, 2*r.bulkChunkSize)
+	for field, value := range fields {
+		values = append(values, field, value)
+		if len(values) == cap(values) {
+			pipeline.HSet(ctx, key, values...)
+			values = make([]interface{}, 0, 2*r.bulkChunkSize)
+		}
+	}
+	if len(values) > 0 {
+		pipeline.HSet(ctx, key, values...)
+	}
+
+	_, err := pipeline.Exec(ctx)
+	return err
+}
+
+func (r *redis) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	status := r.client.HGetAll(ctx, key)
+	if status.Err() != nil && status.Err() != rd.Nil {
+		return map[string]string{}, status.Err()
 	}
-	status := r.client.GeoSearch(ctx, key, &geoSearch)
+
+	return status.Val(), nil
+}
+
+// HDel returns how many of the fields existed
+func (r *redis) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	return r.client.HDel(ctx, key, fields...).Result()
+}
+
+func (r *redis) Del(ctx context.Context, keys ...string) error {
+	return r.client.Del(ctx, keys...).Err()
+}
+
+func (r *redis) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	return r.client.Expire(ctx, key, ttl).Err()
+}
+
+func (r *redis) ZAdd(ctx context.Context, key, member string, score float64) error {
+	return r.client.ZAdd(ctx, key, &rd.Z{Score: score, Member: member}).Err()
+}
+
+// ZRange returns every member of the sorted set ordered by ascending score
+func (r *redis) ZRange(ctx context.Context, key string) ([]string, error) {
+	status := r.client.ZRange(ctx, key, 0, -1)
 	if status.Err() != nil && status.Err() != rd.Nil {
 		return []string{}, status.Err()
 	}
 
 	return status.Val(), nil
 }
+
+func (r *redis) ZRem(ctx context.Context, key string, members ...string) error {
+	values := make([]interface{}, 0, len(members))
+	for _, member := range members {
+		values = append(values, member)
+	}
+
+	return r.client.ZRem(ctx, key, values...).Err()
+}
+
+func (r *redis) Publish(ctx context.Context, channel, message string) error {
+	return r.client.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe listens to the channel until ctx is done, when the returned channel is closed. It returns once the
+// subscription is confirmed, so no message published afterwards is missed.
+func (r *redis) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+	pubSub := r.client.Subscribe(ctx, channel)
+	if _, err := pubSub.Receive(ctx); err != nil {
+		_ = pubSub.Close()
+		return nil, err
+	}
+
+	messages := make(chan string)
+	go func() {
+		defer close(messages)
+		defer pubSub.Close()
+
+		redisMessages := pubSub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-redisMessages:
+				if !ok {
+					return
+				}
+				select {
+				case messages <- message.Payload:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return messages, nil
+}
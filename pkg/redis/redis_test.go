@@ -0,0 +1,174 @@
+package redis_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/internal/config"
+	"github.com/sebastianreh/distance-calculator-api/pkg/logger"
+	"github.com/sebastianreh/distance-calculator-api/pkg/redis"
+	"github.com/sebastianreh/distance-calculator-api/test/standin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const benchmarkRestaurants = 1000
+
+func setup(tb testing.TB, geoAddChunkSize int) redis.Redis {
+	server, err := standin.NewRedisServer()
+	require.NoError(tb, err)
+	tb.Cleanup(func() { _ = server.Close() })
+
+	cfg := config.Config{}
+	cfg.Redis.Host = server.Addr()
+	cfg.Redis.GeoAddChunkSize = geoAddChunkSize
+	client, err := redis.NewRedis(logger.NewLogger(), cfg)
+	require.NoError(tb, err)
+
+	return client
+}
+
+func geoMembers(count int) []redis.GeoMember {
+	members := make([]redis.GeoMember, 0, count)
+	for i := 0; i < count; i++ {
+		members = append(members, redis.GeoMember{
+			ID:   fmt.Sprintf("restaurant%d", i),
+			Lat:  51.4 + float64(i%100)/1000,
+			Long: 0.1 + float64(i/100)/1000,
+		})
+	}
+	return members
+}
+
+func Test_Redis_GeoAddBulk(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("adds every member across chunks", func(t *testing.T) {
+		client := setup(t, 7)
+		members := geoMembers(50)
+
+		err := client.GeoAddBulk(ctx, "restaurants", members)
+		assert.NoError(t, err)
+
+		found, err := client.GeoSearch(ctx, "restaurants", 51.45, 0.1, 100)
+		assert.NoError(t, err)
+		assert.Len(t, found, len(members))
+	})
+
+	t.Run("empty members", func(t *testing.T) {
+		client := setup(t, 7)
+
+		err := client.GeoAddBulk(ctx, "restaurants", nil)
+
+		assert.NoError(t, err)
+	})
+}
+
+func Test_Redis_GeoSearch(t *testing.T) {
+	ctx := context.Background()
+	client := setup(t, 0)
+
+	err := client.GeoAddBulk(ctx, "restaurants", []redis.GeoMember{
+		{ID: "la-pizzeria-del-sur", Lat: -34.6037, Long: -58.3816},
+		{ID: "far", Lat: -34.9, Long: -58.3816},
+	})
+	require.NoError(t, err)
+
+	found, err := client.GeoSearch(ctx, "restaurants", -34.6, -58.38, 5)
+
+	assert.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "la-pizzeria-del-sur", found[0].ID)
+	assert.InDelta(t, -34.6037, found[0].Lat, 1e-6)
+	assert.InDelta(t, -58.3816, found[0].Long, 1e-6)
+}
+
+func Test_Redis_HSetBulk(t *testing.T) {
+	ctx := context.Background()
+	client := setup(t, 3)
+	fields := make(map[string]string)
+	for i := 0; i < 10; i++ {
+		fields[fmt.Sprintf("restaurant-%d", i)] = fmt.Sprintf(`{"radius":%d}`, i)
+	}
+
+	err := client.HSetBulk(ctx, "attributes", fields)
+	assert.NoError(t, err)
+
+	stored, err := client.HGetAll(ctx, "attributes")
+	assert.NoError(t, err)
+	assert.Equal(t, fields, stored)
+}
+
+func Test_Redis_HSetHDel(t *testing.T) {
+	ctx := context.Background()
+	client := setup(t, 0)
+
+	assert.NoError(t, client.HSet(ctx, "overrides", "1", "closed"))
+	assert.NoError(t, client.HSet(ctx, "overrides", "2", "24h"))
+
+	removed, err := client.HDel(ctx, "overrides", "1", "3")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+
+	stored, err := client.HGetAll(ctx, "overrides")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"2": "24h"}, stored)
+}
+
+func Test_Redis_PublishSubscribe(t *testing.T) {
+	client := setup(t, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	messages, err := client.Subscribe(ctx, "datasets")
+	require.NoError(t, err)
+
+	err = client.Publish(ctx, "datasets", "v2")
+	assert.NoError(t, err)
+
+	select {
+	case message := <-messages:
+		assert.Equal(t, "v2", message)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not received")
+	}
+
+	cancel()
+	for range messages {
+	}
+}
+
+// BenchmarkRedis_GeoAdd is the baseline: one round trip per restaurant
+func BenchmarkRedis_GeoAdd(b *testing.B) {
+	ctx := context.Background()
+	client := setup(b, 0)
+	members := geoMembers(benchmarkRestaurants)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, member := range members {
+			if err := client.GeoAdd(ctx, "restaurants", member.ID, member.Lat, member.Long); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkRedis_GeoAddBulk(b *testing.B) {
+	ctx := context.Background()
+	members := geoMembers(benchmarkRestaurants)
+
+	for _, chunkSize := range []int{1, 100, 500, 1000} {
+		b.Run(fmt.Sprintf("chunk-%d", chunkSize), func(b *testing.B) {
+			client := setup(b, chunkSize)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := client.GeoAddBulk(ctx, "restaurants", members); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package schedule
+
+import (
+	"sync"
+	"time"
+)
+
+var locations sync.Map
+
+// LoadLocation is a cached time.LoadLocation, the empty name being UTC
+func LoadLocation(name string) (*time.Location, error) {
+	if location, ok := locations.Load(name); ok {
+		return location.(*time.Location), nil
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, location)
+
+	return location, nil
+}
+
+// LocalTime converts t to the wall clock of the given IANA time zone, falling back to UTC for unknown zones
+func LocalTime(t time.Time, timeZone string) time.Time {
+	location, err := LoadLocation(timeZone)
+	if err != nil {
+		return t.UTC()
+	}
+	return t.In(location)
+}
@@ -0,0 +1,298 @@
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	daysInWeek         = 7
+	minutesInHour      = 60
+	MinutesInDay       = 24 * minutesInHour
+	minutesInWeek      = daysInWeek * MinutesInDay
+	closedDay          = "closed"
+	allDay             = "24h"
+	clockSeparator     = ":"
+	hoursSeparator     = "-"
+	intervalsSeparator = ";"
+	clockParts         = 2
+	intervalParts      = 2
+
+	// HH:MM:SS, seconds are truncated to the minute
+	clockPartsWithSeconds = 3
+	secondsInMinute       = 60
+
+	// Midnight is the start of the day
+	Midnight Clock = 0
+	// EndOfDay is 24:00, only valid as a closing time
+	EndOfDay Clock = MinutesInDay
+)
+
+var (
+	ErrBadClock  = errors.New("bad time format, expected HH:MM")
+	ErrBadFormat = errors.New("bad schedule format, expected HH:MM-HH:MM[;HH:MM-HH:MM...], 24h or closed")
+	// ErrBadOpeningClock is returned for an opening time of 24:00
+	ErrBadOpeningClock = errors.New("bad opening time, expected HH:MM before 24:00")
+
+	// AllDay is the interval of a day open for 24 hours
+	AllDay = Interval{Open: Midnight, Close: EndOfDay}
+	// Closed is a day without opening intervals
+	Closed = Day{}
+)
+
+// Clock is a wall clock time as minutes since midnight, from 00:00 to 24:00. It is encoded to JSON in its
+// HH:MM notation.
+type Clock int
+
+// Interval is an opening interval starting at Open on its own day:
+//   - Open before Close is a same-day interval, open from Open until Close, excluded
+//   - Close before Open crosses midnight, open from Open until Close on the following day
+//   - Open equal to Close, or 00:00-24:00, is open for 24 hours from Open
+type Interval struct {
+	Open  Clock `json:"open"`
+	Close Clock `json:"close"`
+}
+
+// Day holds the opening intervals of one day, a day without intervals is closed
+type Day struct {
+	Intervals []Interval `json:"intervals,omitempty"`
+}
+
+// Week holds a Day per day, indexed by time.Weekday
+type Week [daysInWeek]Day
+
+// ParseClock parses the HH:MM notation, accepting 24:00 as the end of the day. HH:MM:SS is accepted too, the
+// seconds truncated to the minute.
+func ParseClock(value string) (Clock, error) {
+	parts := strings.Split(strings.TrimSpace(value), clockSeparator)
+	if len(parts) != clockParts && len(parts) != clockPartsWithSeconds {
+		return 0, ErrBadClock
+	}
+
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, ErrBadClock
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, ErrBadClock
+	}
+
+	clock := Clock(hours*minutesInHour + minutes)
+	if hours < 0 || minutes < 0 || minutes >= minutesInHour || clock > EndOfDay {
+		return 0, ErrBadClock
+	}
+
+	if len(parts) == clockPartsWithSeconds {
+		seconds, err := strconv.Atoi(parts[2])
+		if err != nil || seconds < 0 || seconds >= secondsInMinute || (clock == EndOfDay && seconds > 0) {
+			return 0, ErrBadClock
+		}
+	}
+
+	return clock, nil
+}
+
+// ParseOpeningClock parses an opening time, which unlike a closing time cannot be 24:00
+func ParseOpeningClock(value string) (Clock, error) {
+	clock, err := ParseClock(value)
+	if err != nil {
+		return 0, err
+	}
+	if clock == EndOfDay {
+		return 0, ErrBadOpeningClock
+	}
+
+	return clock, nil
+}
+
+func NewClock(hour, minute int) Clock {
+	return Clock(hour*minutesInHour + minute)
+}
+
+// ClockOf returns the wall clock time of t, to the minute
+func ClockOf(t time.Time) Clock {
+	return NewClock(t.Hour(), t.Minute())
+}
+
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d", int(c)/minutesInHour, int(c)%minutesInHour)
+}
+
+func (c Clock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+func (c *Clock) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	clock, err := ParseClock(value)
+	if err != nil {
+		return err
+	}
+	*c = clock
+
+	return nil
+}
+
+// Minutes is how long the interval stays open, from 1 minute to a whole day
+func (i Interval) Minutes() int {
+	minutes := (int(i.Close-i.Open)%MinutesInDay + MinutesInDay) % MinutesInDay
+	if minutes == 0 {
+		return MinutesInDay
+	}
+	return minutes
+}
+
+// CrossesMidnight tells if the interval is still open on the following day
+func (i Interval) CrossesMidnight() bool {
+	return int(i.Open)+i.Minutes() > MinutesInDay
+}
+
+// Includes tells if t, in local time, falls within the interval starting on the given weekday, including the
+// part running past midnight into the following day
+func (i Interval) Includes(weekday time.Weekday, t time.Time) bool {
+	minute := int(ClockOf(t))
+	end := int(i.Open) + i.Minutes()
+	switch t.Weekday() {
+	case weekday:
+		return minute >= int(i.Open) && minute < end
+	case (weekday + 1) % daysInWeek:
+		return minute < end-MinutesInDay
+	default:
+		return false
+	}
+}
+
+func (i Interval) IsAllDay() bool {
+	return i.Minutes() == MinutesInDay
+}
+
+func (i Interval) String() string {
+	if i == AllDay {
+		return allDay
+	}
+	return i.Open.String() + hoursSeparator + i.Close.String()
+}
+
+// ParseDay parses the compact notation HH:MM-HH:MM[;HH:MM-HH:MM...], e.g. 11:30-15:00;19:00-23:30, where 24h
+// stands for 00:00-24:00, or "closed"
+func ParseDay(value string) (Day, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, closedDay) {
+		return Closed, nil
+	}
+
+	var day Day
+	for _, rawInterval := range strings.Split(value, intervalsSeparator) {
+		if strings.EqualFold(strings.TrimSpace(rawInterval), allDay) {
+			day.Intervals = append(day.Intervals, AllDay)
+			continue
+		}
+
+		parts := strings.Split(rawInterval, hoursSeparator)
+		if len(parts) != intervalParts {
+			return Day{}, ErrBadFormat
+		}
+
+		openClock, err := ParseOpeningClock(parts[0])
+		if err != nil {
+			return Day{}, ErrBadFormat
+		}
+
+		closeClock, err := ParseClock(parts[1])
+		if err != nil {
+			return Day{}, ErrBadFormat
+		}
+
+		day.Intervals = append(day.Intervals, Interval{Open: openClock, Close: closeClock})
+	}
+
+	return day, nil
+}
+
+func (d Day) IsClosed() bool {
+	return len(d.Intervals) == 0
+}
+
+// String formats the day back to the compact notation ParseDay reads
+func (d Day) String() string {
+	if d.IsClosed() {
+		return closedDay
+	}
+
+	intervals := make([]string, 0, len(d.Intervals))
+	for _, interval := range d.Intervals {
+		intervals = append(intervals, interval.String())
+	}
+	return strings.Join(intervals, intervalsSeparator)
+}
+
+func NewWeek(day Day) Week {
+	var week Week
+	for weekday := range week {
+		week[weekday] = day
+	}
+	return week
+}
+
+// IsOpen evaluates the intervals of the weekday of t, plus the previous day's intervals that run past midnight.
+// t must already be in the restaurant's local time.
+func (w Week) IsOpen(t time.Time) bool {
+	_, _, open := w.OpenInterval(t)
+	return open
+}
+
+// OpenInterval returns the interval including t, in the restaurant's local time, and the minutes left until
+// it closes. Intervals that start before the previous one ends, on the same or the following days, count as
+// the same opening, so a restaurant open 24 hours every day closes a week ahead at most.
+func (w Week) OpenInterval(t time.Time) (Interval, int, bool) {
+	now := int(ClockOf(t))
+	interval, end, open := w.including(t.Weekday(), now)
+	if !open {
+		return Interval{}, 0, false
+	}
+
+	return interval, w.closing(t.Weekday(), end) - now, true
+}
+
+// including returns the interval open at the minute of the weekday and the minute it ends at, counted from
+// the weekday's midnight
+func (w Week) including(weekday time.Weekday, minute int) (Interval, int, bool) {
+	for _, interval := range w[(weekday+daysInWeek-1)%daysInWeek].Intervals {
+		if end := int(interval.Open) + interval.Minutes() - MinutesInDay; minute < end {
+			return interval, end, true
+		}
+	}
+
+	for _, interval := range w[weekday].Intervals {
+		start := int(interval.Open)
+		if end := start + interval.Minutes(); minute >= start && minute < end {
+			return interval, end, true
+		}
+	}
+
+	return Interval{}, 0, false
+}
+
+// closing follows the intervals open at the end of the current one, returning the minute the restaurant
+// closes at counted from the weekday's midnight
+func (w Week) closing(weekday time.Weekday, end int) int {
+	for end < minutesInWeek {
+		day := end / MinutesInDay
+		_, next, open := w.including((weekday+time.Weekday(day))%daysInWeek, end%MinutesInDay)
+		if !open {
+			return end
+		}
+		end = day*MinutesInDay + next
+	}
+
+	return minutesInWeek
+}
@@ -0,0 +1,361 @@
+package schedule_test
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/sebastianreh/distance-calculator-api/pkg/schedule"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	minutesInWeek = 7 * schedule.MinutesInDay
+	propertyRuns  = 2000
+)
+
+// at returns a time of the week starting on Monday 2023-10-16
+func at(day, hour, minute int) time.Time {
+	return time.Date(2023, 10, day, hour, minute, 0, 0, time.UTC)
+}
+
+func interval(openHour, openMinute, closeHour, closeMinute int) schedule.Interval {
+	return schedule.Interval{
+		Open:  schedule.NewClock(openHour, openMinute),
+		Close: schedule.NewClock(closeHour, closeMinute),
+	}
+}
+
+func day(intervals ...schedule.Interval) schedule.Day {
+	return schedule.Day{Intervals: intervals}
+}
+
+func Test_Week_IsOpen(t *testing.T) {
+	week := schedule.NewWeek(day(interval(11, 0, 23, 0)))
+	week[time.Monday] = schedule.Closed
+	week[time.Wednesday] = day(schedule.AllDay)
+	week[time.Friday] = day(interval(11, 30, 15, 0), interval(19, 0, 1, 0))
+	week[time.Saturday] = day(interval(20, 0, 2, 0))
+	week[time.Sunday] = day(interval(12, 0, 16, 0))
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected bool
+	}{
+		{"tuesday within hours", at(17, 12, 0), true},
+		{"tuesday at opening", at(17, 11, 0), true},
+		{"tuesday a minute before closing", at(17, 22, 59), true},
+		{"tuesday at closing", at(17, 23, 0), false},
+		{"tuesday before opening", at(17, 10, 59), false},
+		{"closed monday", at(16, 12, 0), false},
+		{"wednesday 24 hours at midnight", at(18, 0, 0), true},
+		{"wednesday 24 hours last minute", at(18, 23, 59), true},
+		{"thursday after 24 hours", at(19, 0, 0), false},
+		{"saturday night", at(21, 23, 30), true},
+		{"saturday overnight at midnight", at(22, 0, 0), true},
+		{"saturday overnight hours on sunday", at(22, 1, 59), true},
+		{"saturday overnight hours ended", at(22, 2, 0), false},
+		{"shorter sunday hours", at(22, 17, 0), false},
+		{"sunday hours", at(22, 13, 0), true},
+		{"friday lunch shift", at(20, 12, 0), true},
+		{"friday gap between shifts", at(20, 17, 0), false},
+		{"friday dinner shift", at(20, 20, 0), true},
+		{"friday dinner shift past midnight", at(21, 0, 30), true},
+		{"friday dinner shift ended", at(21, 1, 0), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, week.IsOpen(test.now))
+		})
+	}
+}
+
+func Test_Week_OpenInterval(t *testing.T) {
+	tests := []struct {
+		name            string
+		week            schedule.Week
+		now             time.Time
+		expected        schedule.Interval
+		expectedMinutes int
+	}{
+		{"lunch shift", schedule.NewWeek(day(interval(11, 30, 15, 0), interval(19, 0, 1, 30))),
+			at(17, 14, 20), interval(11, 30, 15, 0), 40},
+		{"dinner shift before midnight", schedule.NewWeek(day(interval(11, 30, 15, 0), interval(19, 0, 1, 30))),
+			at(17, 23, 0), interval(19, 0, 1, 30), 150},
+		{"dinner shift after midnight", schedule.NewWeek(day(interval(11, 30, 15, 0), interval(19, 0, 1, 30))),
+			at(18, 1, 0), interval(19, 0, 1, 30), 30},
+		{"closing at the end of the day", schedule.NewWeek(day(interval(19, 0, 24, 0))),
+			at(17, 23, 0), interval(19, 0, 24, 0), 60},
+		{"closing at midnight", schedule.NewWeek(day(interval(19, 0, 0, 0))),
+			at(17, 23, 0), interval(19, 0, 0, 0), 60},
+		{"24 hours from the opening time", schedule.NewWeek(day(interval(10, 0, 10, 0))),
+			at(17, 9, 0), interval(10, 0, 10, 0), 7*schedule.MinutesInDay - 9*60},
+		{"24 hours every day closes a week ahead", schedule.NewWeek(day(schedule.AllDay)),
+			at(17, 6, 0), schedule.AllDay, 7*schedule.MinutesInDay - 6*60},
+		{"shifts back to back", schedule.NewWeek(day(interval(8, 0, 12, 0), interval(12, 0, 16, 0))),
+			at(17, 11, 0), interval(8, 0, 12, 0), 300},
+		{"overnight shift followed by an early one", schedule.NewWeek(day(interval(6, 0, 12, 0), interval(20, 0, 6, 0))),
+			at(17, 23, 0), interval(20, 0, 6, 0), 13 * 60},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			found, minutes, open := test.week.OpenInterval(test.now)
+
+			assert.True(t, open)
+			assert.Equal(t, test.expected, found)
+			assert.Equal(t, test.expectedMinutes, minutes)
+		})
+	}
+
+	t.Run("closed", func(t *testing.T) {
+		_, minutes, open := schedule.NewWeek(schedule.Closed).OpenInterval(at(17, 12, 0))
+
+		assert.False(t, open)
+		assert.Zero(t, minutes)
+	})
+}
+
+func Test_Interval(t *testing.T) {
+	tests := []struct {
+		name            string
+		interval        schedule.Interval
+		minutes         int
+		crossesMidnight bool
+		allDay          bool
+	}{
+		{"same day", interval(11, 0, 15, 0), 240, false, false},
+		{"until the end of the day", interval(19, 0, 24, 0), 300, false, false},
+		{"until midnight", interval(19, 0, 0, 0), 300, false, false},
+		{"cross-midnight", interval(19, 0, 1, 0), 360, true, false},
+		{"24 hours", schedule.AllDay, schedule.MinutesInDay, false, true},
+		{"24 hours from the opening time", interval(10, 0, 10, 0), schedule.MinutesInDay, true, true},
+		{"one minute", interval(23, 59, 0, 0), 1, false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.minutes, test.interval.Minutes())
+			assert.Equal(t, test.crossesMidnight, test.interval.CrossesMidnight())
+			assert.Equal(t, test.allDay, test.interval.IsAllDay())
+		})
+	}
+}
+
+func Test_Interval_Includes(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval schedule.Interval
+		now      time.Time
+		expected bool
+	}{
+		{"same day", interval(17, 0, 20, 0), at(17, 18, 0), true},
+		{"same day at closing", interval(17, 0, 20, 0), at(17, 20, 0), false},
+		{"other weekday", interval(17, 0, 20, 0), at(18, 18, 0), false},
+		{"cross-midnight on its weekday", interval(22, 0, 2, 0), at(17, 23, 0), true},
+		{"cross-midnight on the following day", interval(22, 0, 2, 0), at(18, 1, 59), true},
+		{"cross-midnight ended", interval(22, 0, 2, 0), at(18, 2, 0), false},
+		{"24 hours from the opening time", interval(10, 0, 10, 0), at(18, 9, 59), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.interval.Includes(time.Tuesday, test.now))
+		})
+	}
+}
+
+func Test_ParseDay(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected schedule.Day
+		notation string
+	}{
+		{"single interval", "09:30 - 18:00", day(interval(9, 30, 18, 0)), "09:30-18:00"},
+		{"split shifts", "11:30-15:00;19:00-23:30", day(interval(11, 30, 15, 0), interval(19, 0, 23, 30)),
+			"11:30-15:00;19:00-23:30"},
+		{"cross-midnight", "19:00-01:30", day(interval(19, 0, 1, 30)), "19:00-01:30"},
+		{"closed", "Closed", schedule.Closed, "closed"},
+		{"24 hours", "24H", day(schedule.AllDay), "24h"},
+		{"whole day interval", "00:00-24:00", day(schedule.AllDay), "24h"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parsed, err := schedule.ParseDay(test.value)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, parsed)
+			assert.Equal(t, test.notation, parsed.String())
+		})
+	}
+
+	for _, value := range []string{"11:30-15:00;19:00", "9 to 5", "", "24:00-02:00", "10:60-12:00", "10:00-24:01"} {
+		t.Run("bad format "+value, func(t *testing.T) {
+			_, err := schedule.ParseDay(value)
+
+			assert.ErrorIs(t, err, schedule.ErrBadFormat)
+		})
+	}
+}
+
+func Test_Clock(t *testing.T) {
+	t.Run("every minute of the day round trips", func(t *testing.T) {
+		for clock := schedule.Midnight; clock <= schedule.EndOfDay; clock++ {
+			parsed, err := schedule.ParseClock(clock.String())
+
+			assert.NoError(t, err)
+			assert.Equal(t, clock, parsed)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		data, err := json.Marshal(interval(19, 0, 1, 30))
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"open":"19:00","close":"01:30"}`, string(data))
+
+		var decoded schedule.Interval
+		assert.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, interval(19, 0, 1, 30), decoded)
+		assert.Error(t, json.Unmarshal([]byte(`{"open":1900,"close":130}`), &decoded))
+	})
+
+	t.Run("seconds truncated to the minute", func(t *testing.T) {
+		for value, expected := range map[string]schedule.Clock{
+			"10:30:00": schedule.NewClock(10, 30),
+			"10:30:59": schedule.NewClock(10, 30),
+			"24:00:00": schedule.EndOfDay,
+		} {
+			parsed, err := schedule.ParseClock(value)
+
+			assert.NoError(t, err, value)
+			assert.Equal(t, expected, parsed, value)
+		}
+	})
+
+	t.Run("bad format", func(t *testing.T) {
+		for _, value := range []string{"", "10", "ab:00", "-1:00", "24:30", "10:60", "10:30:99", "10:30:xx",
+			"10:30:-1", "10:30:00:00", "10:30:", "24:00:30"} {
+			_, err := schedule.ParseClock(value)
+
+			assert.ErrorIs(t, err, schedule.ErrBadClock, value)
+		}
+	})
+
+	t.Run("opening time", func(t *testing.T) {
+		opening, err := schedule.ParseOpeningClock("23:59")
+		assert.NoError(t, err)
+		assert.Equal(t, schedule.NewClock(23, 59), opening)
+
+		for _, value := range []string{"24:00", "24:00:00"} {
+			_, err = schedule.ParseOpeningClock(value)
+
+			assert.ErrorIs(t, err, schedule.ErrBadOpeningClock, value)
+		}
+		_, err = schedule.ParseOpeningClock("10:30:99")
+		assert.ErrorIs(t, err, schedule.ErrBadClock)
+	})
+}
+
+// randomWeek generates weeks biased towards the boundaries: midnight, the end of the day, 24 hour and closed days
+type randomWeek schedule.Week
+
+func randomClock(random *rand.Rand) schedule.Clock {
+	switch random.Intn(4) {
+	case 0:
+		return schedule.Midnight
+	case 1:
+		return schedule.NewClock(random.Intn(24), 0)
+	default:
+		return schedule.Clock(random.Intn(schedule.MinutesInDay))
+	}
+}
+
+func (randomWeek) Generate(random *rand.Rand, _ int) reflect.Value {
+	var week schedule.Week
+	for weekday := range week {
+		for i := random.Intn(4); i > 0; i-- {
+			open := randomClock(random)
+			closing := randomClock(random)
+			switch random.Intn(6) {
+			case 0:
+				closing = open
+			case 1:
+				closing = schedule.EndOfDay
+			}
+			week[weekday].Intervals = append(week[weekday].Intervals, schedule.Interval{Open: open, Close: closing})
+		}
+	}
+	return reflect.ValueOf(randomWeek(week))
+}
+
+// openMinutes lays every interval over the minutes of the week, as the reference the schedule is checked against
+func openMinutes(week schedule.Week) []bool {
+	open := make([]bool, minutesInWeek)
+	for weekday, day := range week {
+		for _, interval := range day.Intervals {
+			for minute := 0; minute < interval.Minutes(); minute++ {
+				open[(weekday*schedule.MinutesInDay+int(interval.Open)+minute)%minutesInWeek] = true
+			}
+		}
+	}
+	return open
+}
+
+// minutesUntilClosing counts the covered minutes from the minute of the week on, up to a week after its midnight
+func minutesUntilClosing(covered []bool, minuteOfWeek int) int {
+	limit := minuteOfWeek - minuteOfWeek%schedule.MinutesInDay + minutesInWeek
+	minutes := 0
+	for minuteOfWeek+minutes < limit && covered[(minuteOfWeek+minutes)%minutesInWeek] {
+		minutes++
+	}
+	return minutes
+}
+
+func Test_Week_Properties(t *testing.T) {
+	sunday := time.Date(2023, 10, 15, 0, 0, 0, 0, time.UTC)
+	config := &quick.Config{MaxCount: propertyRuns, Rand: rand.New(rand.NewSource(1))}
+
+	t.Run("open exactly at the minutes covered by an interval", func(t *testing.T) {
+		property := func(week randomWeek, minute uint16) bool {
+			minuteOfWeek := int(minute) % minutesInWeek
+			now := sunday.Add(time.Duration(minuteOfWeek) * time.Minute)
+			return schedule.Week(week).IsOpen(now) == openMinutes(schedule.Week(week))[minuteOfWeek]
+		}
+
+		assert.NoError(t, quick.Check(property, config))
+	})
+
+	t.Run("closes at the first minute not covered, up to a week ahead", func(t *testing.T) {
+		property := func(week randomWeek, minute uint16) bool {
+			minuteOfWeek := int(minute) % minutesInWeek
+			now := sunday.Add(time.Duration(minuteOfWeek) * time.Minute)
+			_, minutes, open := schedule.Week(week).OpenInterval(now)
+
+			expected := minutesUntilClosing(openMinutes(schedule.Week(week)), minuteOfWeek)
+			return open == (expected > 0) && minutes == expected
+		}
+
+		assert.NoError(t, quick.Check(property, config))
+	})
+
+	t.Run("days round trip through their notation", func(t *testing.T) {
+		property := func(week randomWeek) bool {
+			for _, day := range week {
+				parsed, err := schedule.ParseDay(day.String())
+				if err != nil || !reflect.DeepEqual(parsed, day) {
+					return false
+				}
+			}
+			return true
+		}
+
+		assert.NoError(t, quick.Check(property, config))
+	})
+}
@@ -0,0 +1,16 @@
+package source
+
+import (
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/sebastianreh/distance-calculator-api/pkg/logger"
+)
+
+// NewS3SourceAt is NewS3Source signing every request at now, so tests can check the exact signature
+func NewS3SourceAt(endpoint, region, bucket, key, accessKey, secretKey string, logs logger.Logger,
+	restClient *resty.Client, now time.Time) RestaurantSource {
+	s3 := NewS3Source(endpoint, region, bucket, key, accessKey, secretKey, logs, restClient).(*s3Source)
+	s3.now = func() time.Time { return now }
+	return s3
+}
@@ -0,0 +1,34 @@
+package source
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/sebastianreh/distance-calculator-api/pkg/logger"
+	str "github.com/sebastianreh/distance-calculator-api/pkg/strings"
+)
+
+const fileSourceName = "source.file"
+
+type fileSource struct {
+	path string
+	logs logger.Logger
+}
+
+func NewFileSource(path string, logs logger.Logger) RestaurantSource {
+	return &fileSource{
+		path: path,
+		logs: logs,
+	}
+}
+
+func (s *fileSource) Open(_ context.Context) (io.ReadCloser, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		s.logs.Error(str.ErrorConcat(err, fileSourceName, openMethodName))
+		return nil, err
+	}
+
+	return file, nil
+}
@@ -0,0 +1,55 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/sebastianreh/distance-calculator-api/pkg/logger"
+	str "github.com/sebastianreh/distance-calculator-api/pkg/strings"
+)
+
+const (
+	httpSourceName = "source.http"
+	openMethodName = "Open"
+)
+
+type httpSource struct {
+	url        string
+	logs       logger.Logger
+	restClient *resty.Client
+}
+
+func NewHTTPSource(url string, logs logger.Logger, restClient *resty.Client) RestaurantSource {
+	return &httpSource{
+		url:        url,
+		logs:       logs,
+		restClient: restClient,
+	}
+}
+
+func (s *httpSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	return openResponseBody(s.logs, httpSourceName, s.restClient.R().SetContext(ctx), s.url)
+}
+
+// openResponseBody executes a GET without buffering the body so callers can stream it.
+func openResponseBody(logs logger.Logger, sourceName string, req *resty.Request, url string) (io.ReadCloser, error) {
+	resp, err := req.SetDoNotParseResponse(true).Get(url)
+	if err != nil {
+		logs.Error(str.ErrorConcat(err, sourceName, openMethodName))
+		return nil, err
+	}
+
+	body := resp.RawBody()
+	if !resp.IsSuccess() {
+		defer body.Close()
+		message, _ := io.ReadAll(body)
+		err = fmt.Errorf("error getting restaurants https status code: %d, body %s",
+			resp.StatusCode(), string(message))
+		logs.Error(str.ErrorConcat(err, sourceName, openMethodName))
+		return nil, err
+	}
+
+	return body, nil
+}
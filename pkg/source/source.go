@@ -0,0 +1,102 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/sebastianreh/distance-calculator-api/internal/config"
+	"github.com/sebastianreh/distance-calculator-api/pkg/logger"
+)
+
+const (
+	TypeHTTP = "http"
+	TypeFile = "file"
+	TypeS3   = "s3"
+)
+
+// RestaurantSource gives access to the raw restaurants feed, wherever it is stored.
+// Callers must close the returned reader.
+type RestaurantSource interface {
+	Open(ctx context.Context) (io.ReadCloser, error)
+}
+
+type options struct {
+	Type     string
+	URL      string
+	FilePath string
+	S3       s3Options
+}
+
+type s3Options struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	Key       string
+	AccessKey string
+	SecretKey string
+}
+
+// NewRestaurantSource builds the restaurants feed source selected by config.Source.Type
+func NewRestaurantSource(cfg config.Config, logs logger.Logger, restClient *resty.Client) (RestaurantSource, error) {
+	return newSource(options{
+		Type:     cfg.Source.Type,
+		URL:      cfg.Source.URL,
+		FilePath: cfg.Source.FilePath,
+		S3: s3Options{
+			Endpoint:  cfg.Source.S3.Endpoint,
+			Region:    cfg.Source.S3.Region,
+			Bucket:    cfg.Source.S3.Bucket,
+			Key:       cfg.Source.S3.Key,
+			AccessKey: cfg.Source.S3.AccessKey,
+			SecretKey: cfg.Source.S3.SecretKey,
+		},
+	}, logs, restClient)
+}
+
+// NewZonesSource builds the source of the delivery zones file, of the same type as the restaurants feed, or
+// returns nil when no zones file is configured
+func NewZonesSource(cfg config.Config, logs logger.Logger, restClient *resty.Client) (RestaurantSource, error) {
+	opts := options{
+		Type:     cfg.Source.Type,
+		URL:      cfg.Source.ZonesURL,
+		FilePath: cfg.Source.ZonesFilePath,
+		S3: s3Options{
+			Endpoint:  cfg.Source.S3.Endpoint,
+			Region:    cfg.Source.S3.Region,
+			Bucket:    cfg.Source.S3.Bucket,
+			Key:       cfg.Source.S3.ZonesKey,
+			AccessKey: cfg.Source.S3.AccessKey,
+			SecretKey: cfg.Source.S3.SecretKey,
+		},
+	}
+	if opts.URL == "" && opts.FilePath == "" && opts.S3.Key == "" {
+		return nil, nil
+	}
+
+	return newSource(opts, logs, restClient)
+}
+
+func newSource(opts options, logs logger.Logger, restClient *resty.Client) (RestaurantSource, error) {
+	switch opts.Type {
+	case TypeHTTP:
+		if opts.URL == "" {
+			return nil, fmt.Errorf("source %s requires an url", TypeHTTP)
+		}
+		return NewHTTPSource(opts.URL, logs, restClient), nil
+	case TypeFile:
+		if opts.FilePath == "" {
+			return nil, fmt.Errorf("source %s requires a file path", TypeFile)
+		}
+		return NewFileSource(opts.FilePath, logs), nil
+	case TypeS3:
+		if opts.S3.Bucket == "" || opts.S3.Key == "" {
+			return nil, fmt.Errorf("source %s requires a bucket and a key", TypeS3)
+		}
+		return NewS3Source(opts.S3.Endpoint, opts.S3.Region, opts.S3.Bucket, opts.S3.Key,
+			opts.S3.AccessKey, opts.S3.SecretKey, logs, restClient), nil
+	default:
+		return nil, fmt.Errorf("unknown restaurants source type %q", opts.Type)
+	}
+}
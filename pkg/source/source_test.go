@@ -0,0 +1,234 @@
+package source_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/sebastianreh/distance-calculator-api/internal/config"
+	"github.com/sebastianreh/distance-calculator-api/pkg/logger"
+	"github.com/sebastianreh/distance-calculator-api/pkg/source"
+	"github.com/stretchr/testify/assert"
+)
+
+const csvContent = "id,latitude,longitude,availability_radius,open_hour,close_hour,rating\n"
+
+func readAll(t *testing.T, restaurantSource source.RestaurantSource) (string, error) {
+	body, err := restaurantSource.Open(context.Background())
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	content, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	return string(content), nil
+}
+
+// newS3StandIn emulates the subset of a MinIO server used by the S3 source: path style GET object
+// requests signed at now, with the signature computed again from the secret key
+func newS3StandIn(bucket, key, accessKey, secretKey string, now time.Time) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		emptyPayloadHash := sha256.Sum256(nil)
+		amzDate := now.UTC().Format("20060102T150405Z")
+		scope := amzDate[:8] + "/us-east-1/s3/aws4_request"
+		expected := "AWS4-HMAC-SHA256 Credential=" + accessKey + "/" + scope +
+			", SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=" + signV4(r, secretKey, scope)
+		if r.Header.Get("Authorization") != expected || r.Header.Get("X-Amz-Date") != amzDate ||
+			r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(emptyPayloadHash[:]) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("<Error><Code>AccessDenied</Code></Error>"))
+			return
+		}
+		if r.URL.Path != "/"+bucket+"/"+key {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("<Error><Code>NoSuchKey</Code></Error>"))
+			return
+		}
+		_, _ = w.Write([]byte(csvContent))
+	}))
+}
+
+// signV4 computes the AWS Signature V4 of an unsigned payload GET request as S3 does when checking it
+func signV4(r *http.Request, secretKey, scope string) string {
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.RawQuery,
+		"host:" + r.Host,
+		"x-amz-content-sha256:" + r.Header.Get("X-Amz-Content-Sha256"),
+		"x-amz-date:" + r.Header.Get("X-Amz-Date"),
+		"",
+		"host;x-amz-content-sha256;x-amz-date",
+		r.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + r.Header.Get("X-Amz-Date") + "\n" + scope + "\n" +
+		hex.EncodeToString(canonicalRequestHash[:])
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range append(strings.Split(scope, "/"), stringToSign) {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	return hex.EncodeToString(key)
+}
+
+func Test_NewRestaurantSource(t *testing.T) {
+	logs := logger.NewLogger()
+
+	t.Run("unknown source type", func(t *testing.T) {
+		cfg := config.Config{}
+		cfg.Source.Type = "ftp"
+
+		_, err := source.NewRestaurantSource(cfg, logs, resty.New())
+
+		assert.Error(t, err)
+	})
+
+	t.Run("file source without path", func(t *testing.T) {
+		cfg := config.Config{}
+		cfg.Source.Type = source.TypeFile
+
+		_, err := source.NewRestaurantSource(cfg, logs, resty.New())
+
+		assert.Error(t, err)
+	})
+
+	t.Run("file source", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "restaurants.csv")
+		assert.NoError(t, os.WriteFile(path, []byte(csvContent), 0o600))
+		cfg := config.Config{}
+		cfg.Source.Type = source.TypeFile
+		cfg.Source.FilePath = path
+
+		restaurantSource, err := source.NewRestaurantSource(cfg, logs, resty.New())
+		assert.NoError(t, err)
+		content, err := readAll(t, restaurantSource)
+
+		assert.NoError(t, err)
+		assert.Equal(t, csvContent, content)
+	})
+}
+
+func Test_NewZonesSource(t *testing.T) {
+	logs := logger.NewLogger()
+
+	t.Run("no zones file", func(t *testing.T) {
+		cfg := config.Config{}
+		cfg.Source.Type = source.TypeHTTP
+		cfg.Source.URL = "https://example.com/restaurants.csv"
+
+		zonesSource, err := source.NewZonesSource(cfg, logs, resty.New())
+
+		assert.NoError(t, err)
+		assert.Nil(t, zonesSource)
+	})
+
+	t.Run("file source", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "zones.geojson")
+		assert.NoError(t, os.WriteFile(path, []byte(`{"type":"FeatureCollection","features":[]}`), 0o600))
+		cfg := config.Config{}
+		cfg.Source.Type = source.TypeFile
+		cfg.Source.FilePath = filepath.Join(t.TempDir(), "restaurants.csv")
+		cfg.Source.ZonesFilePath = path
+
+		zonesSource, err := source.NewZonesSource(cfg, logs, resty.New())
+		assert.NoError(t, err)
+		content, err := readAll(t, zonesSource)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `{"type":"FeatureCollection","features":[]}`, content)
+	})
+}
+
+func Test_HTTPSource_Open(t *testing.T) {
+	logs := logger.NewLogger()
+
+	t.Run("successful download", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(csvContent))
+		}))
+		defer server.Close()
+
+		content, err := readAll(t, source.NewHTTPSource(server.URL, logs, resty.New()))
+
+		assert.NoError(t, err)
+		assert.Equal(t, csvContent, content)
+	})
+
+	t.Run("unsuccessful status code", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		_, err := readAll(t, source.NewHTTPSource(server.URL, logs, resty.New()))
+
+		assert.Error(t, err)
+	})
+}
+
+func Test_S3Source_Open(t *testing.T) {
+	logs := logger.NewLogger()
+	now := time.Date(2023, 10, 18, 12, 0, 0, 0, time.UTC)
+	server := newS3StandIn("restaurants", "feeds/buenos aires.csv", "minio", "minio123", now)
+	defer server.Close()
+
+	t.Run("successful signed download", func(t *testing.T) {
+		restaurantSource := source.NewS3SourceAt(server.URL, "us-east-1", "restaurants", "feeds/buenos aires.csv",
+			"minio", "minio123", logs, resty.New(), now)
+
+		content, err := readAll(t, restaurantSource)
+
+		assert.NoError(t, err)
+		assert.Equal(t, csvContent, content)
+	})
+
+	t.Run("missing object", func(t *testing.T) {
+		restaurantSource := source.NewS3SourceAt(server.URL, "us-east-1", "restaurants", "feeds/other.csv",
+			"minio", "minio123", logs, resty.New(), now)
+
+		_, err := readAll(t, restaurantSource)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("wrong secret key is rejected", func(t *testing.T) {
+		restaurantSource := source.NewS3SourceAt(server.URL, "us-east-1", "restaurants", "feeds/buenos aires.csv",
+			"minio", "minio456", logs, resty.New(), now)
+
+		_, err := readAll(t, restaurantSource)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("stale request is rejected", func(t *testing.T) {
+		restaurantSource := source.NewS3SourceAt(server.URL, "us-east-1", "restaurants", "feeds/buenos aires.csv",
+			"minio", "minio123", logs, resty.New(), now.Add(-time.Hour))
+
+		_, err := readAll(t, restaurantSource)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("unsigned request is rejected", func(t *testing.T) {
+		restaurantSource := source.NewS3Source(server.URL, "us-east-1", "restaurants", "feeds/buenos aires.csv",
+			"", "", logs, resty.New())
+
+		_, err := readAll(t, restaurantSource)
+
+		assert.Error(t, err)
+	})
+}
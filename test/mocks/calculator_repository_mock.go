@@ -0,0 +1,91 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/sebastianreh/distance-calculator-api/internal/entities"
+	"github.com/stretchr/testify/mock"
+)
+
+type CalculatorRepositoryMock struct {
+	mock.Mock
+}
+
+func NewCalculatorRepositoryMock() *CalculatorRepositoryMock {
+	return new(CalculatorRepositoryMock)
+}
+
+func (m *CalculatorRepositoryMock) RegisterDataset(ctx context.Context, dataset entities.Dataset) error {
+	args := m.Called(ctx, dataset)
+	return args.Error(0)
+}
+
+func (m *CalculatorRepositoryMock) PublishDataset(ctx context.Context, dataset entities.Dataset) error {
+	args := m.Called(ctx, dataset)
+	return args.Error(0)
+}
+
+func (m *CalculatorRepositoryMock) GetCurrentDataset(ctx context.Context) (entities.Dataset, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(entities.Dataset), args.Error(1)
+}
+
+func (m *CalculatorRepositoryMock) WatchDatasets(ctx context.Context) (<-chan entities.Dataset, error) {
+	args := m.Called(ctx)
+	datasets, _ := args.Get(0).(chan entities.Dataset)
+	return datasets, args.Error(1)
+}
+
+func (m *CalculatorRepositoryMock) SetTimeRadiusMapData(ctx context.Context, version string,
+	timeRadiusMap entities.TimeRadiusMap) error {
+	args := m.Called(ctx, version, timeRadiusMap)
+	return args.Error(0)
+}
+
+func (m *CalculatorRepositoryMock) GetTimeRadiusMapData(ctx context.Context, version string) (entities.TimeRadiusMap, error) {
+	args := m.Called(ctx, version)
+	return args.Get(0).(entities.TimeRadiusMap), args.Error(1)
+}
+
+func (m *CalculatorRepositoryMock) SetRestaurantGeoData(ctx context.Context, version string,
+	restaurants entities.Restaurants) error {
+	// the service reuses the batch slice, keep a copy so assertions see what was written
+	args := m.Called(ctx, version, append(entities.Restaurants{}, restaurants...))
+	return args.Error(0)
+}
+
+func (m *CalculatorRepositoryMock) GetRestaurantsInRadius(ctx context.Context, version string, lat, long,
+	radius float64) ([]entities.RestaurantIDLatLng, error) {
+	args := m.Called(ctx, version, lat, long, radius)
+	return args.Get(0).([]entities.RestaurantIDLatLng), args.Error(1)
+}
+
+func (m *CalculatorRepositoryMock) SetOverride(ctx context.Context, override entities.Override) error {
+	args := m.Called(ctx, override)
+	return args.Error(0)
+}
+
+func (m *CalculatorRepositoryMock) GetOverrides(ctx context.Context) ([]entities.Override, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]entities.Override), args.Error(1)
+}
+
+func (m *CalculatorRepositoryMock) DeleteOverride(ctx context.Context, id string) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *CalculatorRepositoryMock) SetExclusion(ctx context.Context, exclusion entities.Exclusion) error {
+	args := m.Called(ctx, exclusion)
+	return args.Error(0)
+}
+
+func (m *CalculatorRepositoryMock) GetExclusions(ctx context.Context) (entities.Exclusions, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(entities.Exclusions), args.Error(1)
+}
+
+func (m *CalculatorRepositoryMock) DeleteExclusion(ctx context.Context, id string) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
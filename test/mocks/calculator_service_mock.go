@@ -20,7 +20,55 @@ func (m *CalculatorServiceMock) CalculateDeliveryRange(ctx context.Context,
 	return args.Get(0).(entities.CalculationResponse), args.Error(1)
 }
 
-func (m *CalculatorServiceMock) PreprocessRestaurants(ctx context.Context) error {
+func (m *CalculatorServiceMock) CalculateBatchDeliveryRange(ctx context.Context,
+	locations []entities.BatchLocation) (entities.BatchCalculationResponse, error) {
+	args := m.Called(ctx, locations)
+	return args.Get(0).(entities.BatchCalculationResponse), args.Error(1)
+}
+
+func (m *CalculatorServiceMock) CheckDeliverability(ctx context.Context,
+	request entities.DeliverabilityRequest) (entities.DeliverabilityResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(entities.DeliverabilityResponse), args.Error(1)
+}
+
+func (m *CalculatorServiceMock) PreprocessRestaurants(ctx context.Context, dryRun bool) (entities.PreprocessReport, error) {
+	args := m.Called(ctx, dryRun)
+	return args.Get(0).(entities.PreprocessReport), args.Error(1)
+}
+
+func (m *CalculatorServiceMock) WatchDatasets(ctx context.Context) {
+	m.Called(ctx)
+}
+
+func (m *CalculatorServiceMock) CreateOverride(ctx context.Context, override entities.Override) (entities.Override,
+	error) {
+	args := m.Called(ctx, override)
+	return args.Get(0).(entities.Override), args.Error(1)
+}
+
+func (m *CalculatorServiceMock) ListOverrides(ctx context.Context, restaurantID string) ([]entities.Override, error) {
+	args := m.Called(ctx, restaurantID)
+	return args.Get(0).([]entities.Override), args.Error(1)
+}
+
+func (m *CalculatorServiceMock) DeleteOverride(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *CalculatorServiceMock) CreateExclusion(ctx context.Context, exclusion entities.Exclusion) (entities.Exclusion,
+	error) {
+	args := m.Called(ctx, exclusion)
+	return args.Get(0).(entities.Exclusion), args.Error(1)
+}
+
+func (m *CalculatorServiceMock) ListExclusions(ctx context.Context) (entities.Exclusions, error) {
 	args := m.Called(ctx)
+	return args.Get(0).(entities.Exclusions), args.Error(1)
+}
+
+func (m *CalculatorServiceMock) DeleteExclusion(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
@@ -0,0 +1,25 @@
+package mocks
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type RestaurantSourceMock struct {
+	mock.Mock
+}
+
+func NewRestaurantSourceMock() *RestaurantSourceMock {
+	return new(RestaurantSourceMock)
+}
+
+func (m *RestaurantSourceMock) Open(ctx context.Context) (io.ReadCloser, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return io.NopCloser(strings.NewReader(args.String(0))), args.Error(1)
+}
@@ -0,0 +1,444 @@
+package standin
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	mathFormulas "github.com/sebastianreh/distance-calculator-api/pkg/math_formulas"
+)
+
+const bitSize = 64
+
+// connection serializes writes to a client, since published messages are pushed from other connections
+type connection struct {
+	mu     sync.Mutex
+	writer *bufio.Writer
+}
+
+type geoPoint struct {
+	lat  float64
+	long float64
+}
+
+// RedisServer is a minimal in-memory server speaking the Redis protocol over TCP. It implements just the
+// commands used by the application so tests and benchmarks exercise real network round trips without
+// requiring a Redis installation.
+type RedisServer struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu      sync.Mutex
+	strings map[string]string
+	geo     map[string]map[string]geoPoint
+	zsets   map[string]map[string]float64
+	hashes  map[string]map[string]string
+	// subscribed connections by channel
+	channels map[string]map[*connection]struct{}
+}
+
+func NewRedisServer() (*RedisServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	server := &RedisServer{
+		listener: listener,
+		strings:  make(map[string]string),
+		geo:      make(map[string]map[string]geoPoint),
+		zsets:    make(map[string]map[string]float64),
+		hashes:   make(map[string]map[string]string),
+		channels: make(map[string]map[*connection]struct{}),
+	}
+
+	server.wg.Add(1)
+	go server.accept()
+
+	return server, nil
+}
+
+func (s *RedisServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *RedisServer) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *RedisServer) accept() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.serve(conn)
+	}
+}
+
+func (s *RedisServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	client := &connection{writer: bufio.NewWriter(conn)}
+	defer s.unsubscribe(client)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+
+		if len(args) > 0 && strings.EqualFold(args[0], "publish") {
+			s.publish(client, args[1:])
+			continue
+		}
+
+		client.mu.Lock()
+		s.execute(client, args)
+		// flush only when the client has nothing else queued, so pipelines get a single response write
+		if reader.Buffered() == 0 {
+			err = client.writer.Flush()
+		}
+		client.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := readLine(reader)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+
+	count, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		header, err := readLine(reader)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(header, "$") {
+			return nil, errors.New("protocol error: expected bulk string")
+		}
+		size, err := strconv.Atoi(header[1:])
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err = io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args = append(args, string(data[:size]))
+	}
+
+	return args, nil
+}
+
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (s *RedisServer) execute(client *connection, args []string) {
+	w := client.writer
+	if len(args) == 0 {
+		writeError(w, "ERR empty command")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch strings.ToLower(args[0]) {
+	case "ping":
+		fmt.Fprint(w, "+PONG\r\n")
+	case "get":
+		value, ok := s.strings[args[1]]
+		if !ok {
+			fmt.Fprint(w, "$-1\r\n")
+			return
+		}
+		writeBulk(w, value)
+	case "set":
+		s.strings[args[1]] = args[2]
+		fmt.Fprint(w, "+OK\r\n")
+	case "del":
+		deleted := 0
+		for _, key := range args[1:] {
+			if s.delete(key) {
+				deleted++
+			}
+		}
+		writeInteger(w, deleted)
+	case "expire":
+		writeInteger(w, 1)
+	case "geoadd":
+		s.geoAdd(w, args[1:])
+	case "geosearch":
+		s.geoSearch(w, args[1:])
+	case "zadd":
+		s.zAdd(w, args[1:])
+	case "zrange":
+		s.zRange(w, args[1])
+	case "zrem":
+		removed := 0
+		for _, member := range args[2:] {
+			if _, ok := s.zsets[args[1]][member]; ok {
+				delete(s.zsets[args[1]], member)
+				removed++
+			}
+		}
+		writeInteger(w, removed)
+	case "hset":
+		s.hSet(w, args[1:])
+	case "hgetall":
+		fields := make([]string, 0, 2*len(s.hashes[args[1]]))
+		for field, value := range s.hashes[args[1]] {
+			fields = append(fields, field, value)
+		}
+		writeArray(w, fields)
+	case "hdel":
+		removed := 0
+		for _, field := range args[2:] {
+			if _, ok := s.hashes[args[1]][field]; ok {
+				delete(s.hashes[args[1]], field)
+				removed++
+			}
+		}
+		writeInteger(w, removed)
+	case "subscribe":
+		for _, channel := range args[1:] {
+			if s.channels[channel] == nil {
+				s.channels[channel] = make(map[*connection]struct{})
+			}
+			s.channels[channel][client] = struct{}{}
+			fmt.Fprint(w, "*3\r\n")
+			writeBulk(w, "subscribe")
+			writeBulk(w, channel)
+			writeInteger(w, len(args)-1)
+		}
+	default:
+		writeError(w, fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+}
+
+// publish handles PUBLISH channel message, pushing the message to every subscribed connection. The server lock
+// is released before writing to subscribers, which take their own connection lock.
+func (s *RedisServer) publish(client *connection, args []string) {
+	s.mu.Lock()
+	subscribers := make([]*connection, 0, len(s.channels[args[0]]))
+	for subscriber := range s.channels[args[0]] {
+		subscribers = append(subscribers, subscriber)
+	}
+	s.mu.Unlock()
+
+	for _, subscriber := range subscribers {
+		subscriber.mu.Lock()
+		fmt.Fprint(subscriber.writer, "*3\r\n")
+		writeBulk(subscriber.writer, "message")
+		writeBulk(subscriber.writer, args[0])
+		writeBulk(subscriber.writer, args[1])
+		_ = subscriber.writer.Flush()
+		subscriber.mu.Unlock()
+	}
+
+	client.mu.Lock()
+	writeInteger(client.writer, len(subscribers))
+	_ = client.writer.Flush()
+	client.mu.Unlock()
+}
+
+func (s *RedisServer) unsubscribe(client *connection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, subscribers := range s.channels {
+		delete(subscribers, client)
+	}
+}
+
+func (s *RedisServer) delete(key string) bool {
+	_, isString := s.strings[key]
+	_, isGeo := s.geo[key]
+	_, isZSet := s.zsets[key]
+	_, isHash := s.hashes[key]
+	delete(s.strings, key)
+	delete(s.geo, key)
+	delete(s.zsets, key)
+	delete(s.hashes, key)
+	return isString || isGeo || isZSet || isHash
+}
+
+// geoAdd handles GEOADD key longitude latitude member [longitude latitude member ...]
+func (s *RedisServer) geoAdd(w *bufio.Writer, args []string) {
+	const memberArgs = 3
+	if len(args) < 1+memberArgs || (len(args)-1)%memberArgs != 0 {
+		writeError(w, "ERR wrong number of arguments for 'geoadd' command")
+		return
+	}
+
+	points, ok := s.geo[args[0]]
+	if !ok {
+		points = make(map[string]geoPoint)
+		s.geo[args[0]] = points
+	}
+
+	added := 0
+	for i := 1; i < len(args); i += memberArgs {
+		long, longErr := strconv.ParseFloat(args[i], bitSize)
+		lat, latErr := strconv.ParseFloat(args[i+1], bitSize)
+		if longErr != nil || latErr != nil {
+			writeError(w, "ERR value is not a valid float")
+			return
+		}
+		if _, exists := points[args[i+2]]; !exists {
+			added++
+		}
+		points[args[i+2]] = geoPoint{lat: lat, long: long}
+	}
+
+	writeInteger(w, added)
+}
+
+// geoSearch handles GEOSEARCH key FROMLONLAT longitude latitude BYRADIUS radius km [WITHCOORD]
+func (s *RedisServer) geoSearch(w *bufio.Writer, args []string) {
+	var long, lat, radius float64
+	var withCoord bool
+	for i := 1; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "withcoord":
+			withCoord = true
+		case "fromlonlat":
+			long, _ = strconv.ParseFloat(args[i+1], bitSize)
+			lat, _ = strconv.ParseFloat(args[i+2], bitSize)
+			i += 2
+		case "byradius":
+			radius, _ = strconv.ParseFloat(args[i+1], bitSize)
+			i += 2
+		}
+	}
+
+	if lat < -85.05112878 || lat > 85.05112878 || long < -180 || long > 180 {
+		writeError(w, fmt.Sprintf("ERR invalid longitude,latitude pair %f,%f", long, lat))
+		return
+	}
+
+	members := make([]string, 0)
+	for member, point := range s.geo[args[0]] {
+		if mathFormulas.Haversine(lat, long, point.lat, point.long) <= radius {
+			members = append(members, member)
+		}
+	}
+	sort.Strings(members)
+
+	if !withCoord {
+		writeArray(w, members)
+		return
+	}
+
+	fmt.Fprintf(w, "*%d\r\n", len(members))
+	for _, member := range members {
+		point := s.geo[args[0]][member]
+		fmt.Fprint(w, "*2\r\n")
+		writeBulk(w, member)
+		writeArray(w, []string{strconv.FormatFloat(point.long, 'f', -1, bitSize),
+			strconv.FormatFloat(point.lat, 'f', -1, bitSize)})
+	}
+}
+
+// hSet handles HSET key field value [field value ...]
+func (s *RedisServer) hSet(w *bufio.Writer, args []string) {
+	if len(args) < 3 || (len(args)-1)%2 != 0 {
+		writeError(w, "ERR wrong number of arguments for 'hset' command")
+		return
+	}
+
+	fields, ok := s.hashes[args[0]]
+	if !ok {
+		fields = make(map[string]string)
+		s.hashes[args[0]] = fields
+	}
+
+	added := 0
+	for i := 1; i < len(args); i += 2 {
+		if _, exists := fields[args[i]]; !exists {
+			added++
+		}
+		fields[args[i]] = args[i+1]
+	}
+
+	writeInteger(w, added)
+}
+
+func (s *RedisServer) zAdd(w *bufio.Writer, args []string) {
+	members, ok := s.zsets[args[0]]
+	if !ok {
+		members = make(map[string]float64)
+		s.zsets[args[0]] = members
+	}
+
+	added := 0
+	for i := 1; i+1 < len(args); i += 2 {
+		score, err := strconv.ParseFloat(args[i], bitSize)
+		if err != nil {
+			writeError(w, "ERR value is not a valid float")
+			return
+		}
+		if _, exists := members[args[i+1]]; !exists {
+			added++
+		}
+		members[args[i+1]] = score
+	}
+
+	writeInteger(w, added)
+}
+
+func (s *RedisServer) zRange(w *bufio.Writer, key string) {
+	members := make([]string, 0, len(s.zsets[key]))
+	for member := range s.zsets[key] {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return s.zsets[key][members[i]] < s.zsets[key][members[j]]
+	})
+
+	writeArray(w, members)
+}
+
+func writeError(w *bufio.Writer, message string) {
+	fmt.Fprintf(w, "-%s\r\n", message)
+}
+
+func writeInteger(w *bufio.Writer, value int) {
+	fmt.Fprintf(w, ":%d\r\n", value)
+}
+
+func writeBulk(w *bufio.Writer, value string) {
+	fmt.Fprintf(w, "$%d\r\n%s\r\n", len(value), value)
+}
+
+func writeArray(w *bufio.Writer, values []string) {
+	fmt.Fprintf(w, "*%d\r\n", len(values))
+	for _, value := range values {
+		writeBulk(w, value)
+	}
+}